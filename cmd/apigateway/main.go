@@ -1,40 +1,67 @@
 package main
 
 import (
-	"bytes"
+	"context"
 	"flag"
 	"fmt"
-	"io/ioutil"
-	"math/rand"
-	"net"
+	"io"
 	"net/http"
 	"net/http/httputil"
 	"os"
 	"os/signal"
-	"strconv"
 	"strings"
 	"syscall"
-	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/hashicorp/consul/api"
+
+	"github.com/tocgis/api-gateway/pkg/loadbalance"
+	"github.com/tocgis/api-gateway/pkg/middleware"
+	"github.com/tocgis/api-gateway/pkg/routing"
 )
 
 var (
-	consulHost = flag.String("consul.host", "10.10.10.107", "consul server ip address")
-	consulPort = flag.String("consul.port", "8500", "consul server port")
+	consulHost    = flag.String("consul.host", "10.10.10.107", "consul server ip address")
+	consulPort    = flag.String("consul.port", "8500", "consul server port")
+	lbConfig      = flag.String("lb.config", "", "path to the load balancing config file (JSON), per-service strategy/health-check/circuit-breaker settings")
+	mwConfig      = flag.String("middleware.config", "", "path to the middleware config file (JSON): JWT auth, rate limiting and per-route middleware enablement")
+	routingConfig = flag.String("routing.config", "", "path to the routing rule table (JSON); reloaded on SIGHUP. Empty keeps the legacy 'first path segment == service name' convention")
+	routingKVKey  = flag.String("routing.consul-kv", "", "Consul KV key holding the routing rule table (JSON); watched for changes instead of/in addition to -routing.config")
+	tlsCert       = flag.String("tls.cert", "", "path to the TLS certificate used to terminate TLS on :8003 (requires -tls.key)")
+	tlsKey        = flag.String("tls.key", "", "path to the TLS private key used to terminate TLS on :8003 (requires -tls.cert)")
 )
 
-type ResponseMap struct {
-	Msg  string
-	Code int
+// proxyCtxKey 用于在 Director 和 ErrorHandler/ModifyResponse 之间通过
+// request context 传递选中的服务名与实例，避免重复查询 Consul。
+type proxyCtxKey struct{}
+
+// proxyCtx 记录一次代理请求选中的服务、实例与命中的路由规则，供
+// ErrorHandler/ModifyResponse 上报熔断结果及应用响应头改写。
+type proxyCtx struct {
+	serviceName string
+	instance    *loadbalance.Instance
+	rule        *routing.Rule
+	// cancel 在规则配置了超时覆盖时非空，ModifyResponse/ErrorHandler 负责
+	// 在请求结束后调用它以释放 context.WithTimeout 关联的定时器。
+	cancel context.CancelFunc
+	// reported 在 ModifyResponse 已把结果上报熔断器并归还负载均衡器状态后
+	// 置为 true；ModifyResponse 返回错误时 ErrorHandler 据此避免重复上报/归还。
+	reported bool
 }
 
-var responseMap ResponseMap
+// cancelOnCloseBody 包装响应体，在客户端（ReverseProxy）读完并 Close 之后才
+// 调用 cancel，而不是在 ModifyResponse 返回时就调用——此时 body 还没开始被
+// 拷贝，过早 cancel 会截断响应。
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnCloseBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
 
-/**
- *
- */
 func main() {
 	flag.Parse()
 
@@ -54,7 +81,74 @@ func main() {
 		os.Exit(1)
 	}
 
-	proxy := NewReverseProxy(consulClient, logger)
+	var lbCfg loadbalance.Config
+	if *lbConfig != "" {
+		lbCfg, err = loadbalance.LoadConfig(*lbConfig)
+		if err != nil {
+			logger.Log("err", err)
+			os.Exit(1)
+		}
+	}
+	transport := newBackendTransports(lbCfg)
+	lbManager := loadbalance.NewManager(lbCfg, consulInstanceFetcher(consulClient), logger)
+
+	var routingCfg routing.Config
+	if *routingConfig != "" {
+		routingCfg, err = routing.LoadConfig(*routingConfig)
+		if err != nil {
+			logger.Log("err", err)
+			os.Exit(1)
+		}
+	}
+	routingTable, err := routing.NewTable(routingCfg, logger)
+	if err != nil {
+		logger.Log("err", err)
+		os.Exit(1)
+	}
+	if *routingConfig != "" {
+		go watchSIGHUP(routingTable, *routingConfig, logger)
+	}
+	if *routingKVKey != "" {
+		go routing.WatchConsulKV(consulClient, *routingKVKey, routingTable, make(chan struct{}))
+	}
+
+	proxy := NewReverseProxy(consulClient, lbManager, routingTable, transport, logger)
+	wsProxy := websocketProxy(lbManager, routingTable, transport, logger)
+
+	gatewayHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isWebsocketUpgrade(r) {
+			wsProxy.ServeHTTP(w, r)
+			return
+		}
+		proxy.ServeHTTP(w, r)
+	})
+
+	var mwCfg middleware.Config
+	if *mwConfig != "" {
+		mwCfg, err = middleware.LoadConfig(*mwConfig)
+		if err != nil {
+			logger.Log("err", err)
+			os.Exit(1)
+		}
+	}
+	registry := middleware.Registry{
+		"access_log": middleware.NewAccessLog(logger),
+		"rate_limit": middleware.NewRateLimiter(mwCfg.RateLimit),
+		"metrics":    middleware.NewMetrics(),
+	}
+	if mwCfg.JWT.Algorithm != "" || mwCfg.JWT.HMACSecret != "" || mwCfg.JWT.JWKSURL != "" {
+		jwtAuth, err := middleware.NewJWTAuth(mwCfg.JWT, logger)
+		if err != nil {
+			logger.Log("err", err)
+			os.Exit(1)
+		}
+		registry["jwt_auth"] = jwtAuth
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", middleware.Handler())
+	mux.Handle("/", middleware.NewRouter(mwCfg.Routes, registry)(gatewayHandler))
+	handler := http.Handler(mux)
 
 	errChan := make(chan error)
 	go func() {
@@ -64,94 +158,147 @@ func main() {
 	}()
 
 	go func() {
+		if *tlsCert != "" && *tlsKey != "" {
+			logger.Log("transport", "https", "addr", "8003")
+			errChan <- http.ListenAndServeTLS(":8003", *tlsCert, *tlsKey, handler)
+			return
+		}
 		logger.Log("transport", "http", "addr", "8003")
-		handler := proxy
 		errChan <- http.ListenAndServe(":8003", handler)
 	}()
 
 	logger.Log("exit", <-errChan)
 }
 
-var transport = &http.Transport{
-	DialContext: (&net.Dialer{
-		Timeout:   30 * time.Second, //连接超时
-		KeepAlive: 30 * time.Second, //长连接超时时间
-	}).DialContext,
-	MaxIdleConns:          100,              //最大空闲连接
-	IdleConnTimeout:       90 * time.Second, //空闲超时时间
-	TLSHandshakeTimeout:   10 * time.Second, //tls握手超时时间
-	ExpectContinueTimeout: 1 * time.Second,  //100-continue 超时时间
+// consulInstanceFetcher 返回一个按服务名查询 Consul 目录、转换为
+// loadbalance.Instance 列表的 InstanceFetcher。
+func consulInstanceFetcher(client *api.Client) loadbalance.InstanceFetcher {
+	return func(serviceName string) ([]*loadbalance.Instance, error) {
+		result, _, err := client.Catalog().Service(serviceName, "", nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(result) == 0 {
+			return nil, fmt.Errorf("no such service instance: %s", serviceName)
+		}
+
+		instances := make([]*loadbalance.Instance, 0, len(result))
+		for _, svc := range result {
+			weight := 1
+			if svc.ServiceWeights.Passing > 0 {
+				weight = svc.ServiceWeights.Passing
+			}
+			instances = append(instances, loadbalance.NewInstance(svc.ServiceID, svc.ServiceAddress, svc.ServicePort, weight, hasTag(svc.ServiceTags, "https")))
+		}
+		return instances, nil
+	}
+}
+
+// watchSIGHUP 收到 SIGHUP 时重新加载 path 指向的路由规则表，便于操作者
+// 不重启网关即可生效新的路由配置。
+func watchSIGHUP(table *routing.Table, path string, logger log.Logger) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGHUP)
+	for range c {
+		if err := table.ReloadFromFile(path); err != nil {
+			logger.Log("routing", "reload failed", "path", path, "err", err.Error())
+		}
+	}
+}
+
+// hasTag 判断 tags 中是否包含 target（大小写不敏感）。
+func hasTag(tags []string, target string) bool {
+	for _, tag := range tags {
+		if strings.EqualFold(tag, target) {
+			return true
+		}
+	}
+	return false
 }
 
 // NewReverseProxy 反向代理
-func NewReverseProxy(client *api.Client, logger log.Logger) *httputil.ReverseProxy {
+func NewReverseProxy(client *api.Client, lbManager *loadbalance.Manager, routingTable *routing.Table, transport *backendTransports, logger log.Logger) *httputil.ReverseProxy {
 
 	// proxy Director 请求协调者  对请求进行设置 修改
 	director := func(r *http.Request) {
 
-		//查询原始请求路径，如：/user/users/5
-		reqPath := r.URL.Path
-		logger.Log("request Path:", reqPath)
-
-		if reqPath == "" {
-			return
-		}
-
-		//按照分隔符'/'对路径进行分解，获取服务名称serviceName
-		pathArray := strings.Split(reqPath, "/")
-		serviceName := pathArray[1]
-		logger.Log("serviceName:", serviceName)
+		logger.Log("request Path:", r.URL.Path)
 
-		//调用consul api查询serviceName的服务实例列表
-		result, _, err := client.Catalog().Service(serviceName, "", nil)
+		//按路由规则表（或未配置规则表时的旧约定）解析出目标服务/实例，应用
+		//规则中的路径重写与请求头改写
+		backend, err := resolveBackend(routingTable, lbManager, r)
 		if err != nil {
-			logger.Log("reverseProxy failed", "query service instance error", err.Error())
+			logger.Log("reverseProxy failed", "resolve backend error", err.Error())
 			return
 		}
+		logger.Log("serviceName:", backend.serviceName, "service id", backend.instance.ServiceID)
 
-		if len(result) == 0 {
-			logger.Log("reverseProxy failed", "no such service instance", serviceName)
-			return
+		//设置代理服务地址信息：携带 https 标签的实例走 TLS，由 backendTransports 拨号
+		if backend.instance.TLS {
+			r.URL.Scheme = "https"
+		} else {
+			r.URL.Scheme = "http"
 		}
+		r.URL.Host = fmt.Sprintf("%s:%d", backend.instance.Address, backend.instance.Port)
+		r.URL.Path = backend.destPath
 
-		//重新组织请求路径，去掉服务名称部分
-		destPath := strings.Join(pathArray[2:], "/")
-
-		//随机选择一个服务实例
-		tgt := result[rand.Int()%len(result)]
-		logger.Log("service id", tgt.ServiceID)
+		//token 校验由 pkg/middleware 的 JWT 中间件在到达这里之前完成，校验通过后的
+		//claim 会以 X-User-* Header 传递，此处只设置真实来源 IP
+		r.Header.Set("X-Real-Ip", r.RemoteAddr)
 
-		//设置代理服务地址信息
-		r.URL.Scheme = "http"
-		r.URL.Host = fmt.Sprintf("%s:%d", tgt.ServiceAddress, tgt.ServicePort)
-		r.URL.Path = "/" + destPath
+		//规则配置了超时覆盖时收紧请求 context；cancel 存入 proxyCtx，由
+		//ModifyResponse/ErrorHandler 在请求结束后调用，避免 context 泄漏
+		ctx := r.Context()
+		var cancel context.CancelFunc
+		if backend.timeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, backend.timeout)
+		}
 
-		// TODO: 如要在API网关当中加入 token的验证，在此处解析完token之后，以Header 参数进行传递
-		//只在第一代理中设置此header头
-		r.Header.Set("X-Real-Ip", r.RemoteAddr)
+		//记录本次选中的服务、实例与命中的规则，供 ErrorHandler/ModifyResponse 使用
+		*r = *r.WithContext(context.WithValue(ctx, proxyCtxKey{}, &proxyCtx{serviceName: backend.serviceName, instance: backend.instance, rule: backend.rule, cancel: cancel}))
 	}
 
 	//更改内容
 	modifyFunc := func(resp *http.Response) error {
-		//请求以下命令：curl 'http://127.0.0.1:2002/error'
-		if resp.StatusCode != 200 || resp.StatusCode != 201 || resp.StatusCode != 203 || resp.StatusCode != 204 {
-			//获取内容
-			oldPayload, err := ioutil.ReadAll(resp.Body)
-			if err != nil {
-				return err
+		//将本次响应的成败反馈给被动熔断器：5xx 视为一次失败，其余视为成功；
+		//命中规则时按规则配置改写响应头，并跑一遍该规则配置的 body 改写插件链
+		if pc, ok := resp.Request.Context().Value(proxyCtxKey{}).(*proxyCtx); ok {
+			//注意：此时响应体还没有被拷贝给客户端（ReverseProxy 在 ModifyResponse
+			//返回之后才读取 resp.Body），这里不能直接 cancel 掉 context，否则会在
+			//body 读到一半时把底层请求取消掉，导致响应被截断。cancel 改为挂在
+			//body 的 Close 上，等 ReverseProxy 拷贝完 body 调用 Close 时再释放。
+			if pc.cancel != nil {
+				resp.Body = &cancelOnCloseBody{ReadCloser: resp.Body, cancel: pc.cancel}
+			}
+			lbManager.RecordResult(pc.serviceName, pc.instance, resp.StatusCode < http.StatusInternalServerError)
+			lbManager.Release(pc.serviceName, pc.instance)
+			pc.reported = true
+			if pc.rule != nil {
+				pc.rule.ApplyResponseHeaders(resp.Header)
+				if err := pc.rule.ResponseTransform().Apply(resp); err != nil {
+					logger.Log("reverseProxy failed", "response transform error", err.Error())
+					return err
+				}
 			}
-			// body 追加内容
-			newPayload := []byte("" + string(oldPayload))
-			resp.Body = ioutil.NopCloser(bytes.NewBuffer(newPayload))
-
-			// head 修改追加内容
-			resp.ContentLength = int64(len(newPayload))
-			resp.Header.Set("Content-Length", strconv.FormatInt(int64(len(newPayload)), 10))
 		}
 		return nil
 	}
 
-	errFunc := func(w http.ResponseWriter, r *http.Request, err error) {
+	errFunc := func(w http.ResponseWriter, r *http.Request, proxyErr error) {
+		//连接失败/超时同样计入被动熔断器的失败次数，并归还 Pick 占用的负载均衡器状态。
+		//连接失败/超时时响应体从未产生；ModifyResponse 返回错误时 ReverseProxy 会丢弃
+		//该响应且结果已由 ModifyResponse 上报过。两种情况下直接 cancel 都不会截断
+		//发给客户端的数据。
+		if pc, ok := r.Context().Value(proxyCtxKey{}).(*proxyCtx); ok {
+			if pc.cancel != nil {
+				defer pc.cancel()
+			}
+			if !pc.reported {
+				lbManager.RecordResult(pc.serviceName, pc.instance, false)
+				lbManager.Release(pc.serviceName, pc.instance)
+			}
+		}
+
 		//查询原始请求路径，如：/user/users/5
 		reqPath := r.URL.Path
 
@@ -162,7 +309,9 @@ func NewReverseProxy(client *api.Client, logger log.Logger) *httputil.ReversePro
 		//按照分隔符'/'对路径进行分解，获取服务名称serviceName
 		pathArray := strings.Split(reqPath, "/")
 		serviceName := pathArray[1]
-		//调用consul api查询serviceName的服务实例列表
+		//调用consul api查询serviceName的服务实例列表，仅用于区分"服务不存在"和
+		//"服务存在但后端不可达"两种情况；这里的错误与上面的 proxyErr 无关，不能
+		//覆盖它，否则服务存在时 proxyErr 为 nil 会导致下面 Error() 空指针 panic
 		result, _, err := client.Catalog().Service(serviceName, "", nil)
 		if err != nil {
 			logger.Log("reverseProxy failed", "query service instance error", err.Error())
@@ -170,11 +319,11 @@ func NewReverseProxy(client *api.Client, logger log.Logger) *httputil.ReversePro
 		}
 
 		if len(result) == 0 {
-			http.Error(w, serviceName + " Not Found", 404)
+			http.Error(w, serviceName+" Not Found", 404)
 			return
 		}
 
-		http.Error(w, ""+err.Error(), 500)
+		http.Error(w, ""+proxyErr.Error(), 500)
 	}
 
 	return &httputil.ReverseProxy{
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tocgis/api-gateway/pkg/loadbalance"
+	"github.com/tocgis/api-gateway/pkg/routing"
+)
+
+// errEmptyPath 在请求路径为空时返回，调用方直接放弃代理。
+var errEmptyPath = errors.New("empty request path")
+
+// errNoMatchingRule 表示路由规则表非空，但没有一条规则命中该请求；命中的
+// 规则既没有配置 service 也没有配置 static_urls 时同样返回它。
+var errNoMatchingRule = errors.New("no routing rule matched request")
+
+// resolvedBackend 是一次代理请求解析出的转发目标。
+type resolvedBackend struct {
+	serviceName string
+	destPath    string
+	instance    *loadbalance.Instance
+	rule        *routing.Rule
+	timeout     time.Duration
+}
+
+// resolveBackend 先在 table 中按 match 条件查找第一条命中的规则，按其
+// target/rewrite 配置解出转发地址；当规则表为空（未配置规则文件）时，
+// 回退到旧的约定："第一段路径 == Consul 服务名，其余部分转发"。
+// Director 与 WebSocket 代理共用这一逻辑，避免选路规则出现分歧。
+func resolveBackend(table *routing.Table, lbManager *loadbalance.Manager, r *http.Request) (*resolvedBackend, error) {
+	if r.URL.Path == "" {
+		return nil, errEmptyPath
+	}
+
+	if rule := table.Match(r); rule != nil {
+		return resolveViaRule(rule, lbManager, r)
+	}
+	if !table.Empty() {
+		return nil, errNoMatchingRule
+	}
+	return resolveViaLegacyConvention(lbManager, r)
+}
+
+// resolveViaRule 解析一条已命中的路由规则。
+func resolveViaRule(rule *routing.Rule, lbManager *loadbalance.Manager, r *http.Request) (*resolvedBackend, error) {
+	destPath := rule.RewritePath(r.URL.Path)
+	rule.ApplyRequestHeaders(r.Header)
+
+	if serviceName := rule.Service(); serviceName != "" {
+		tgt, err := lbManager.Pick(serviceName, r)
+		if err != nil {
+			return nil, err
+		}
+		return &resolvedBackend{serviceName: serviceName, destPath: destPath, instance: tgt, rule: rule, timeout: rule.Timeout()}, nil
+	}
+
+	target := rule.NextStaticURL()
+	if target == nil {
+		return nil, errNoMatchingRule
+	}
+	inst, err := staticInstance(target)
+	if err != nil {
+		return nil, err
+	}
+	return &resolvedBackend{serviceName: target.Host, destPath: destPath, instance: inst, rule: rule, timeout: rule.Timeout()}, nil
+}
+
+// resolveViaLegacyConvention 实现网关原本的约定：第一段路径即 Consul 服务名。
+func resolveViaLegacyConvention(lbManager *loadbalance.Manager, r *http.Request) (*resolvedBackend, error) {
+	pathArray := strings.Split(r.URL.Path, "/")
+	serviceName := pathArray[1]
+
+	tgt, err := lbManager.Pick(serviceName, r)
+	if err != nil {
+		return nil, err
+	}
+
+	destPath := "/" + strings.Join(pathArray[2:], "/")
+	return &resolvedBackend{serviceName: serviceName, destPath: destPath, instance: tgt}, nil
+}
+
+// staticInstance 把一条静态目标 URL 转换为 loadbalance.Instance，供规则
+// 直接转发到固定地址（不经过 Consul）的场景使用。
+func staticInstance(target *url.URL) (*loadbalance.Instance, error) {
+	host, portStr, err := net.SplitHostPort(target.Host)
+	if err != nil {
+		host = target.Host
+		if target.Scheme == "https" {
+			portStr = "443"
+		} else {
+			portStr = "80"
+		}
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, err
+	}
+	return loadbalance.NewInstance(target.Host, host, port, 1, target.Scheme == "https"), nil
+}
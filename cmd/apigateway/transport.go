@@ -0,0 +1,101 @@
+package main
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http2"
+
+	"github.com/tocgis/api-gateway/pkg/loadbalance"
+)
+
+// backendTransports 按服务名缓存一个 http.RoundTripper，以便携带 https 标签的
+// 服务使用各自配置的根 CA / InsecureSkipVerify 拨号并在 TLS 握手时通过 ALPN
+// 协商升级到 HTTP/2；配置了 h2c 的服务改用明文 HTTP/2（prior knowledge，不
+// 经 TLS/ALPN 协商）直连后端。
+type backendTransports struct {
+	lbCfg loadbalance.Config
+
+	mu    sync.Mutex
+	cache map[string]http.RoundTripper
+}
+
+func newBackendTransports(lbCfg loadbalance.Config) *backendTransports {
+	return &backendTransports{
+		lbCfg: lbCfg,
+		cache: make(map[string]http.RoundTripper),
+	}
+}
+
+// forService 返回（必要时创建）serviceName 对应的 http.RoundTripper。
+func (b *backendTransports) forService(serviceName string) (http.RoundTripper, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if t, ok := b.cache[serviceName]; ok {
+		return t, nil
+	}
+
+	svcCfg := b.lbCfg.ForService(serviceName)
+
+	if svcCfg.H2C {
+		t := &http2.Transport{
+			// AllowHTTP + 一个返回明文连接的 DialTLS 是 net/http2 支持
+			// cleartext h2c（prior knowledge，不走 Upgrade 握手）的标准做法。
+			AllowHTTP: true,
+			DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+				return (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).Dial(network, addr)
+			},
+		}
+		b.cache[serviceName] = t
+		return t, nil
+	}
+
+	tlsCfg, err := loadbalance.BackendTLSConfig(svcCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		TLSClientConfig:       tlsCfg,
+	}
+	// 允许向声明了 h2 的 TLS 后端通过 ALPN 自动升级到 HTTP/2。
+	if err := http2.ConfigureTransport(t); err != nil {
+		return nil, err
+	}
+
+	b.cache[serviceName] = t
+	return t, nil
+}
+
+// tlsConfigForService 返回 serviceName 配置的后端 TLS 校验参数，供非
+// ReverseProxy 路径（如 WebSocket 的手动 TLS 拨号）复用，而不是各自
+// 硬编码一份校验行为。
+func (b *backendTransports) tlsConfigForService(serviceName string) (*tls.Config, error) {
+	return loadbalance.BackendTLSConfig(b.lbCfg.ForService(serviceName))
+}
+
+// RoundTrip 实现 http.RoundTripper，依据 Director 写入请求 context 的
+// serviceName 挑选对应的后端 http.RoundTripper。
+func (b *backendTransports) RoundTrip(r *http.Request) (*http.Response, error) {
+	pc, ok := r.Context().Value(proxyCtxKey{}).(*proxyCtx)
+	if !ok {
+		return http.DefaultTransport.RoundTrip(r)
+	}
+	t, err := b.forService(pc.serviceName)
+	if err != nil {
+		return nil, err
+	}
+	return t.RoundTrip(r)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+
+	"github.com/tocgis/api-gateway/pkg/loadbalance"
+	"github.com/tocgis/api-gateway/pkg/routing"
+)
+
+// isWebsocketUpgrade 判断请求是否要求升级为 WebSocket 连接。
+func isWebsocketUpgrade(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Connection"), "upgrade") &&
+		strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
+
+// websocketProxy 劫持客户端连接，拨号到选中的后端实例，并在两端之间
+// 双向转发字节流，用于代理 ws://、wss:// 流量。httputil.ReverseProxy
+// 不支持 Hijack，因此 WebSocket 升级请求绕过它单独处理。
+func websocketProxy(lbManager *loadbalance.Manager, routingTable *routing.Table, transports *backendTransports, logger log.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		backend, err := resolveBackend(routingTable, lbManager, r)
+		if err != nil {
+			logger.Log("wsProxy failed", "resolve backend error", err.Error())
+			http.Error(w, "Bad Gateway", http.StatusBadGateway)
+			return
+		}
+
+		backendConn, err := dialBackend(backend.instance, backend.serviceName, transports)
+		if err != nil {
+			logger.Log("wsProxy failed", "dial backend error", err.Error(), "serviceName", backend.serviceName)
+			lbManager.RecordResult(backend.serviceName, backend.instance, false)
+			lbManager.Release(backend.serviceName, backend.instance)
+			http.Error(w, "Bad Gateway", http.StatusBadGateway)
+			return
+		}
+		defer backendConn.Close()
+
+		hijacker, ok := w.(http.Hijacker)
+		if !ok {
+			logger.Log("wsProxy failed", "response writer does not support hijacking")
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		clientConn, clientBuf, err := hijacker.Hijack()
+		if err != nil {
+			logger.Log("wsProxy failed", "hijack error", err.Error())
+			return
+		}
+		defer clientConn.Close()
+
+		//保留 Sec-WebSocket-* 等升级相关的请求头，原样转发握手请求行。
+		outReq := r.Clone(r.Context())
+		outReq.URL.Path = backend.destPath
+		if err := outReq.Write(backendConn); err != nil {
+			logger.Log("wsProxy failed", "write handshake error", err.Error())
+			lbManager.RecordResult(backend.serviceName, backend.instance, false)
+			lbManager.Release(backend.serviceName, backend.instance)
+			return
+		}
+
+		errCh := make(chan error, 2)
+		go proxyCopy(errCh, backendConn, clientBuf)
+		go proxyCopy(errCh, clientConn, backendConn)
+
+		//任意一个方向结束（客户端或后端关闭连接）即认为会话结束。
+		err = <-errCh
+		success := err == nil || err == io.EOF
+		lbManager.RecordResult(backend.serviceName, backend.instance, success)
+		lbManager.Release(backend.serviceName, backend.instance)
+	})
+}
+
+// dialBackend 按实例是否携带 https 标签选择明文或 TLS 拨号；TLS 拨号复用
+// serviceName 配置的根 CA / InsecureSkipVerify（与 ReverseProxy 的
+// backendTransports 走同一份配置，不再各自硬编码一份校验行为）。
+func dialBackend(tgt *loadbalance.Instance, serviceName string, transports *backendTransports) (net.Conn, error) {
+	addr := net.JoinHostPort(tgt.Address, strconv.Itoa(tgt.Port))
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	if !tgt.TLS {
+		return dialer.Dial("tcp", addr)
+	}
+	tlsCfg, err := transports.tlsConfigForService(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	return tls.DialWithDialer(dialer, "tcp", addr, tlsCfg)
+}
+
+// proxyCopy 从 src 拷贝到 dst，结束后把错误（或 nil）写入 done。
+func proxyCopy(done chan<- error, dst io.Writer, src io.Reader) {
+	_, err := io.Copy(dst, src)
+	done <- err
+}
@@ -0,0 +1,39 @@
+// Package jsonutil holds small JSON decoding helpers shared across the
+// gateway's config packages.
+package jsonutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Duration is a time.Duration that decodes from a human-readable JSON
+// string (e.g. "10s", "500ms") via time.ParseDuration, instead of
+// encoding/json's default of a plain integer number of nanoseconds, which
+// is not what operators write in config files.
+type Duration time.Duration
+
+// UnmarshalJSON parses d from a JSON string.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("jsonutil: duration must be a string like \"10s\": %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("jsonutil: invalid duration %q: %w", s, err)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalJSON renders d the same way it's read: as a duration string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Duration returns d as a standard time.Duration.
+func (d Duration) Duration() time.Duration {
+	return time.Duration(d)
+}
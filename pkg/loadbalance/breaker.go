@@ -0,0 +1,136 @@
+package loadbalance
+
+import (
+	"sync"
+	"time"
+)
+
+// breakerState 是单个实例熔断器的状态机阶段。
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// CircuitBreakerConfig 配置被动熔断行为。
+type CircuitBreakerConfig struct {
+	// FailureThreshold 是触发熔断的连续失败次数：第 FailureThreshold 次连续
+	// 失败即打开熔断器，默认 5。
+	FailureThreshold int
+	// Cooldown 是熔断打开后到进入半开状态尝试放行探测请求的时长，默认 30s。
+	Cooldown time.Duration
+}
+
+// withDefaults 填充未设置的字段为合理默认值。
+func (c CircuitBreakerConfig) withDefaults() CircuitBreakerConfig {
+	if c.FailureThreshold <= 0 {
+		c.FailureThreshold = 5
+	}
+	if c.Cooldown <= 0 {
+		c.Cooldown = 30 * time.Second
+	}
+	return c
+}
+
+// instanceBreaker 记录单个实例的连续失败次数与熔断状态。
+type instanceBreaker struct {
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+	// probing 为 true 时表示半开状态下已经放行了一个探测请求，结果还没
+	// 返回（RecordSuccess/RecordFailure 尚未调用），此时必须拒绝其余并发
+	// 请求，否则半开状态会放行无限流量而不是单个探测请求。
+	probing bool
+}
+
+// CircuitBreaker 在某个实例连续返回 5xx 或超时达到阈值后将其标记为不健康，
+// 并在冷却时间过后放行一个探测请求（半开状态），成功则恢复、失败则重新熔断。
+type CircuitBreaker struct {
+	cfg      CircuitBreakerConfig
+	mu       sync.Mutex
+	breakers map[string]*instanceBreaker
+}
+
+// NewCircuitBreaker 创建一个熔断器，每个实例的状态按 ServiceID 区分。
+func NewCircuitBreaker(cfg CircuitBreakerConfig) *CircuitBreaker {
+	return &CircuitBreaker{
+		cfg:      cfg.withDefaults(),
+		breakers: make(map[string]*instanceBreaker),
+	}
+}
+
+// breakerFor 返回（必要时创建）指定实例的熔断状态。
+func (cb *CircuitBreaker) breakerFor(inst *Instance) *instanceBreaker {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	b, ok := cb.breakers[inst.ServiceID]
+	if !ok {
+		b = &instanceBreaker{}
+		cb.breakers[inst.ServiceID] = b
+	}
+	return b
+}
+
+// Allow 报告是否允许向该实例放行请求：熔断打开期间拒绝，冷却结束后
+// 放行一个探测请求进入半开状态。
+func (cb *CircuitBreaker) Allow(inst *Instance) bool {
+	b := cb.breakerFor(inst)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < cb.cfg.Cooldown {
+			return false
+		}
+		b.state = breakerHalfOpen
+		b.probing = true
+		return true
+	case breakerHalfOpen:
+		// 探测请求的结果还没通过 RecordSuccess/RecordFailure 返回，
+		// 期间到达的其余请求一律拒绝，只放行一个探测请求。
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// RecordSuccess 记录一次成功调用，半开探测成功后熔断器关闭、计数清零。
+func (cb *CircuitBreaker) RecordSuccess(inst *Instance) {
+	b := cb.breakerFor(inst)
+	b.mu.Lock()
+	b.state = breakerClosed
+	b.failures = 0
+	b.probing = false
+	b.mu.Unlock()
+	inst.SetHealthy(true)
+}
+
+// RecordFailure 记录一次 5xx/超时失败，达到阈值或半开探测失败时触发熔断。
+func (cb *CircuitBreaker) RecordFailure(inst *Instance) {
+	b := cb.breakerFor(inst)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == breakerHalfOpen {
+		b.state = breakerOpen
+		b.openedAt = time.Now()
+		b.probing = false
+		inst.SetHealthy(false)
+		return
+	}
+
+	b.failures++
+	if b.failures >= cb.cfg.FailureThreshold {
+		b.state = breakerOpen
+		b.openedAt = time.Now()
+		inst.SetHealthy(false)
+	}
+}
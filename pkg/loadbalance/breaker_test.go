@@ -0,0 +1,88 @@
+package loadbalance
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerOpensAfterThreshold(t *testing.T) {
+	cb := NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 3, Cooldown: time.Hour})
+	inst := NewInstance("a", "10.0.0.1", 8080, 1, false)
+
+	for i := 0; i < 2; i++ {
+		if !cb.Allow(inst) {
+			t.Fatalf("Allow before threshold reached = false, want true")
+		}
+		cb.RecordFailure(inst)
+	}
+	if !cb.Allow(inst) {
+		t.Fatalf("Allow on the failure that trips the breaker = false, want true (it hasn't opened yet)")
+	}
+	cb.RecordFailure(inst)
+
+	if cb.Allow(inst) {
+		t.Fatalf("Allow after breaker opened = true, want false")
+	}
+	if inst.Healthy() {
+		t.Fatalf("instance still marked healthy after breaker opened")
+	}
+}
+
+func TestCircuitBreakerHalfOpenAllowsSingleProbe(t *testing.T) {
+	cb := NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, Cooldown: time.Millisecond})
+	inst := NewInstance("a", "10.0.0.1", 8080, 1, false)
+
+	cb.RecordFailure(inst) // trips the breaker open
+	time.Sleep(5 * time.Millisecond)
+
+	if !cb.Allow(inst) {
+		t.Fatalf("first Allow after cooldown = false, want true (single probe)")
+	}
+	// Concurrent/subsequent callers must be denied until the probe's result
+	// comes back via RecordSuccess/RecordFailure.
+	for i := 0; i < 5; i++ {
+		if cb.Allow(inst) {
+			t.Fatalf("Allow while a probe is in flight = true, want false")
+		}
+	}
+}
+
+func TestCircuitBreakerHalfOpenSuccessCloses(t *testing.T) {
+	cb := NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, Cooldown: time.Millisecond})
+	inst := NewInstance("a", "10.0.0.1", 8080, 1, false)
+
+	cb.RecordFailure(inst)
+	time.Sleep(5 * time.Millisecond)
+
+	if !cb.Allow(inst) {
+		t.Fatalf("probe Allow = false, want true")
+	}
+	cb.RecordSuccess(inst)
+
+	if !inst.Healthy() {
+		t.Fatalf("instance not marked healthy after successful probe")
+	}
+	if !cb.Allow(inst) {
+		t.Fatalf("Allow after breaker closed = false, want true")
+	}
+}
+
+func TestCircuitBreakerHalfOpenFailureReopens(t *testing.T) {
+	cb := NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, Cooldown: time.Millisecond})
+	inst := NewInstance("a", "10.0.0.1", 8080, 1, false)
+
+	cb.RecordFailure(inst)
+	time.Sleep(5 * time.Millisecond)
+
+	if !cb.Allow(inst) {
+		t.Fatalf("probe Allow = false, want true")
+	}
+	cb.RecordFailure(inst)
+
+	if cb.Allow(inst) {
+		t.Fatalf("Allow right after a failed probe = true, want false (breaker reopened)")
+	}
+	if inst.Healthy() {
+		t.Fatalf("instance still marked healthy after failed probe")
+	}
+}
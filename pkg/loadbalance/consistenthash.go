@@ -0,0 +1,74 @@
+package loadbalance
+
+import (
+	"fmt"
+	"hash/crc32"
+	"sort"
+	"sync"
+)
+
+// virtualNodesPerInstance 是 ketama 风格哈希环上每个真实实例对应的虚拟节点数。
+const virtualNodesPerInstance = 160
+
+// ConsistentHash 基于 ketama 风格的一致性哈希环选择实例，使相同 key 的
+// 请求稳定落在同一个后端上，用于粘性会话等有状态场景。
+type ConsistentHash struct {
+	mu      sync.RWMutex
+	ring    []uint32
+	nodeMap map[uint32]*Instance
+}
+
+// NewConsistentHash 创建一个一致性哈希负载均衡器。
+func NewConsistentHash(instances []*Instance) *ConsistentHash {
+	c := &ConsistentHash{}
+	c.Update(instances)
+	return c
+}
+
+// Pick 使用 key 在哈希环上查找顺时针方向最近的健康实例。当 key 为空时
+// 退化为取环上第一个健康实例。
+func (c *ConsistentHash) Pick(key string) (*Instance, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if len(c.ring) == 0 {
+		return nil, ErrNoHealthyInstance
+	}
+
+	hash := crc32.ChecksumIEEE([]byte(key))
+	idx := sort.Search(len(c.ring), func(i int) bool { return c.ring[i] >= hash })
+
+	// 环形查找：找不到比 hash 更大的节点时回绕到第一个节点。
+	for i := 0; i < len(c.ring); i++ {
+		pos := (idx + i) % len(c.ring)
+		inst := c.nodeMap[c.ring[pos]]
+		if inst.Healthy() {
+			return inst, nil
+		}
+	}
+	return nil, ErrNoHealthyInstance
+}
+
+// Release 实现 LoadBalancer；一致性哈希不跟踪每实例状态，no-op。
+func (c *ConsistentHash) Release(_ *Instance) {}
+
+// Update 实现 LoadBalancer，重建哈希环。
+func (c *ConsistentHash) Update(instances []*Instance) {
+	ring := make([]uint32, 0, len(instances)*virtualNodesPerInstance)
+	nodeMap := make(map[uint32]*Instance, len(instances)*virtualNodesPerInstance)
+
+	for _, inst := range instances {
+		for v := 0; v < virtualNodesPerInstance; v++ {
+			vKey := fmt.Sprintf("%s#%d#%d", inst.ServiceID, inst.Port, v)
+			hash := crc32.ChecksumIEEE([]byte(vKey))
+			ring = append(ring, hash)
+			nodeMap[hash] = inst
+		}
+	}
+	sort.Slice(ring, func(i, j int) bool { return ring[i] < ring[j] })
+
+	c.mu.Lock()
+	c.ring = ring
+	c.nodeMap = nodeMap
+	c.mu.Unlock()
+}
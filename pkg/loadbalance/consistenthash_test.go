@@ -0,0 +1,120 @@
+package loadbalance
+
+import (
+	"fmt"
+	"hash/crc32"
+	"testing"
+)
+
+func TestConsistentHashPickIsStable(t *testing.T) {
+	instances := []*Instance{
+		NewInstance("a", "10.0.0.1", 8080, 1, false),
+		NewInstance("b", "10.0.0.2", 8080, 1, false),
+		NewInstance("c", "10.0.0.3", 8080, 1, false),
+	}
+	ch := NewConsistentHash(instances)
+
+	first, err := ch.Pick("user-42")
+	if err != nil {
+		t.Fatalf("Pick: %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		inst, err := ch.Pick("user-42")
+		if err != nil {
+			t.Fatalf("Pick: %v", err)
+		}
+		if inst.ServiceID != first.ServiceID {
+			t.Fatalf("Pick(%q) = %s, want stable %s", "user-42", inst.ServiceID, first.ServiceID)
+		}
+	}
+}
+
+func TestConsistentHashPickDistributesAcrossKeys(t *testing.T) {
+	instances := []*Instance{
+		NewInstance("a", "10.0.0.1", 8080, 1, false),
+		NewInstance("b", "10.0.0.2", 8080, 1, false),
+		NewInstance("c", "10.0.0.3", 8080, 1, false),
+	}
+	ch := NewConsistentHash(instances)
+
+	seen := make(map[string]bool)
+	for i := 0; i < 200; i++ {
+		inst, err := ch.Pick(keyFor(i))
+		if err != nil {
+			t.Fatalf("Pick: %v", err)
+		}
+		seen[inst.ServiceID] = true
+	}
+	if len(seen) != len(instances) {
+		t.Fatalf("expected keys to spread across all %d instances, only hit %v", len(instances), seen)
+	}
+}
+
+func TestConsistentHashPickWrapsAroundRing(t *testing.T) {
+	// A single instance owns every node on the ring, so any key must resolve
+	// to it. Most keys land inside the ring, so a key hashing past the ring's
+	// last node is searched for and picked too, which always exercises the
+	// wraparound branch in Pick.
+	instances := []*Instance{NewInstance("only", "10.0.0.1", 8080, 1, false)}
+	ch := NewConsistentHash(instances)
+
+	for i := 0; i < 50; i++ {
+		inst, err := ch.Pick(keyFor(i))
+		if err != nil {
+			t.Fatalf("Pick: %v", err)
+		}
+		if inst.ServiceID != "only" {
+			t.Fatalf("Pick = %s, want only", inst.ServiceID)
+		}
+	}
+
+	last := ch.ring[len(ch.ring)-1]
+	wrapKey := ""
+	for i := 0; wrapKey == "" && i < 1000000; i++ {
+		if k := fmt.Sprintf("wrap-%d", i); crc32.ChecksumIEEE([]byte(k)) > last {
+			wrapKey = k
+		}
+	}
+	if wrapKey == "" {
+		t.Fatalf("no key found hashing past the ring's last node %d", last)
+	}
+	inst, err := ch.Pick(wrapKey)
+	if err != nil {
+		t.Fatalf("Pick(%q) past the last node: %v", wrapKey, err)
+	}
+	if inst.ServiceID != "only" {
+		t.Fatalf("Pick(%q) past the last node = %s, want only", wrapKey, inst.ServiceID)
+	}
+}
+
+func TestConsistentHashPickSkipsUnhealthyInstances(t *testing.T) {
+	a := NewInstance("a", "10.0.0.1", 8080, 1, false)
+	b := NewInstance("b", "10.0.0.2", 8080, 1, false)
+	ch := NewConsistentHash([]*Instance{a, b})
+
+	a.SetHealthy(false)
+	b.SetHealthy(false)
+	if _, err := ch.Pick("any-key"); err != ErrNoHealthyInstance {
+		t.Fatalf("Pick with no healthy instances = %v, want ErrNoHealthyInstance", err)
+	}
+
+	b.SetHealthy(true)
+	inst, err := ch.Pick("any-key")
+	if err != nil {
+		t.Fatalf("Pick: %v", err)
+	}
+	if inst.ServiceID != "b" {
+		t.Fatalf("Pick = %s, want the only healthy instance b", inst.ServiceID)
+	}
+}
+
+func TestConsistentHashPickEmptyRing(t *testing.T) {
+	ch := NewConsistentHash(nil)
+	if _, err := ch.Pick("key"); err != ErrNoHealthyInstance {
+		t.Fatalf("Pick on empty ring = %v, want ErrNoHealthyInstance", err)
+	}
+}
+
+func keyFor(i int) string {
+	return "user-" + string(rune('a'+i%26)) + string(rune('0'+i/26))
+}
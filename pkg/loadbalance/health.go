@@ -0,0 +1,119 @@
+package loadbalance
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+// HealthCheckConfig 配置某个服务的主动健康检查行为。
+type HealthCheckConfig struct {
+	// Path 是探测路径，如 "/health"，默认 "/health"。
+	Path string
+	// Interval 是探测周期，默认 10s。
+	Interval time.Duration
+	// Timeout 是单次探测的超时时间，默认 2s。
+	Timeout time.Duration
+}
+
+// withDefaults 填充未设置的字段为合理默认值。
+func (c HealthCheckConfig) withDefaults() HealthCheckConfig {
+	if c.Path == "" {
+		c.Path = "/health"
+	}
+	if c.Interval <= 0 {
+		c.Interval = 10 * time.Second
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 2 * time.Second
+	}
+	return c
+}
+
+// HealthChecker 周期性地探测一组实例的健康端点，并据此更新其健康状态。
+type HealthChecker struct {
+	cfg    HealthCheckConfig
+	client *http.Client
+	logger log.Logger
+	stopCh chan struct{}
+
+	mu        sync.Mutex
+	instances []*Instance
+}
+
+// NewHealthChecker 创建一个健康检查器，调用 Start 后开始周期性探测 instances。
+func NewHealthChecker(instances []*Instance, cfg HealthCheckConfig, logger log.Logger) *HealthChecker {
+	cfg = cfg.withDefaults()
+	return &HealthChecker{
+		cfg:       cfg,
+		instances: instances,
+		// 探测只用于判断存活，TLS 实例证书校验失败不应影响健康判定。
+		client: &http.Client{Timeout: cfg.Timeout, Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}},
+		logger: logger,
+		stopCh: make(chan struct{}),
+	}
+}
+
+// UpdateInstances 替换被探测的实例集合，在 Consul 刷新产生新的 *Instance
+// 集合后调用，使探测结果始终作用于实际参与选路的实例，而不是被刷新淘汰、
+// 再也不会被 Pick 选中的旧实例。
+func (h *HealthChecker) UpdateInstances(instances []*Instance) {
+	h.mu.Lock()
+	h.instances = instances
+	h.mu.Unlock()
+}
+
+// Start 启动后台探测 goroutine，直到 Stop 被调用。
+func (h *HealthChecker) Start() {
+	go func() {
+		ticker := time.NewTicker(h.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				h.probeAll()
+			case <-h.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止健康检查。
+func (h *HealthChecker) Stop() {
+	close(h.stopCh)
+}
+
+// probeAll 对每个实例发起一次探测。
+func (h *HealthChecker) probeAll() {
+	h.mu.Lock()
+	instances := h.instances
+	h.mu.Unlock()
+
+	for _, inst := range instances {
+		healthy := h.probe(inst)
+		if healthy != inst.Healthy() {
+			h.logger.Log("healthcheck", inst.ServiceID, "address", inst.Address, "healthy", healthy)
+		}
+		inst.SetHealthy(healthy)
+	}
+}
+
+// probe 对单个实例执行一次 HTTP 探测。
+func (h *HealthChecker) probe(inst *Instance) bool {
+	scheme := "http"
+	if inst.TLS {
+		scheme = "https"
+	}
+	url := fmt.Sprintf("%s://%s:%d%s", scheme, inst.Address, inst.Port, h.cfg.Path)
+	resp, err := h.client.Get(url)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode >= 200 && resp.StatusCode < 300
+}
@@ -0,0 +1,57 @@
+package loadbalance
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+)
+
+// KeySourceConfig 描述一致性哈希策略如何从请求中提取哈希 key，用于将持有
+// 会话/文档状态的后端固定绑定同一批请求（粘性会话）。
+type KeySourceConfig struct {
+	// Headers 按顺序尝试读取的请求头名称，取第一个非空值作为 key。
+	Headers []string `json:"headers"`
+	// PathPatterns 按顺序尝试匹配的正则表达式，匹配 r.URL.Path；若包含
+	// 捕获组则取第一个捕获组作为 key，否则取整个匹配内容。
+	PathPatterns []string `json:"path_patterns"`
+}
+
+// KeySource 编译好的 KeySourceConfig，可重复用于多个请求。
+type KeySource struct {
+	headers  []string
+	patterns []*regexp.Regexp
+}
+
+// NewKeySource 编译 cfg 中的正则表达式，构造一个可复用的 KeySource。
+func NewKeySource(cfg KeySourceConfig) (*KeySource, error) {
+	ks := &KeySource{headers: cfg.Headers}
+	for _, pattern := range cfg.PathPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("loadbalance: invalid path pattern %q: %w", pattern, err)
+		}
+		ks.patterns = append(ks.patterns, re)
+	}
+	return ks, nil
+}
+
+// Extract 依次尝试配置的请求头、路径正则，都未命中时回退到完整请求路径，
+// 保证一致性哈希策略始终能拿到一个可用的 key。
+func (k *KeySource) Extract(r *http.Request) string {
+	for _, header := range k.headers {
+		if v := r.Header.Get(header); v != "" {
+			return v
+		}
+	}
+	for _, re := range k.patterns {
+		m := re.FindStringSubmatch(r.URL.Path)
+		if m == nil {
+			continue
+		}
+		if len(m) > 1 {
+			return m[1]
+		}
+		return m[0]
+	}
+	return r.URL.Path
+}
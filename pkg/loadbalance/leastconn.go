@@ -0,0 +1,50 @@
+package loadbalance
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// LeastConnections 选择当前活跃连接数最少的健康实例。
+type LeastConnections struct {
+	mu        sync.Mutex
+	instances []*Instance
+}
+
+// NewLeastConnections 创建一个最小连接数负载均衡器。
+func NewLeastConnections(instances []*Instance) *LeastConnections {
+	return &LeastConnections{instances: instances}
+}
+
+// Pick 实现 LoadBalancer。选中后会递增该实例的活跃连接计数，调用方
+// 需要在请求结束后调用 Release 归还计数。
+func (l *LeastConnections) Pick(_ string) (*Instance, error) {
+	l.mu.Lock()
+	healthy := healthyInstances(l.instances)
+	l.mu.Unlock()
+
+	if len(healthy) == 0 {
+		return nil, ErrNoHealthyInstance
+	}
+
+	best := healthy[0]
+	for _, inst := range healthy[1:] {
+		if atomic.LoadInt64(&inst.activeConns) < atomic.LoadInt64(&best.activeConns) {
+			best = inst
+		}
+	}
+	atomic.AddInt64(&best.activeConns, 1)
+	return best, nil
+}
+
+// Release 归还一次 Pick 增加的活跃连接计数，应在代理请求完成后调用。
+func (l *LeastConnections) Release(inst *Instance) {
+	atomic.AddInt64(&inst.activeConns, -1)
+}
+
+// Update 实现 LoadBalancer。
+func (l *LeastConnections) Update(instances []*Instance) {
+	l.mu.Lock()
+	l.instances = instances
+	l.mu.Unlock()
+}
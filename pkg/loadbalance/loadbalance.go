@@ -0,0 +1,110 @@
+// Package loadbalance 提供网关按服务对后端实例进行选路的可插拔策略。
+package loadbalance
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrNoHealthyInstance 表示某个服务当前没有可用的健康实例。
+var ErrNoHealthyInstance = errors.New("loadbalance: no healthy instance available")
+
+// Instance 表示一个后端服务实例。
+type Instance struct {
+	ServiceID string
+	Address   string
+	Port      int
+	// Weight 用于加权轮询，默认值为 1。
+	Weight int
+	// TLS 标记该实例是否在 Consul 中携带了 https 标签，需要通过 TLS 拨号。
+	TLS bool
+
+	mu          sync.RWMutex
+	healthy     bool
+	activeConns int64
+
+	// current 是平滑加权轮询算法使用的当前权重游标。
+	current int
+}
+
+// NewInstance 创建一个默认健康的后端实例。tls 表示该实例是否需要通过 TLS 访问
+// （通常来自 Consul 中的 https 标签）。
+func NewInstance(serviceID, address string, port, weight int, tls bool) *Instance {
+	if weight <= 0 {
+		weight = 1
+	}
+	return &Instance{
+		ServiceID: serviceID,
+		Address:   address,
+		Port:      port,
+		Weight:    weight,
+		TLS:       tls,
+		healthy:   true,
+	}
+}
+
+// Healthy 返回该实例当前是否可接受流量。
+func (i *Instance) Healthy() bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.healthy
+}
+
+// SetHealthy 更新实例的健康状态，由健康检查或熔断器调用。
+func (i *Instance) SetHealthy(healthy bool) {
+	i.mu.Lock()
+	i.healthy = healthy
+	i.mu.Unlock()
+}
+
+// LoadBalancer 按某种策略从一组实例中选出一个后端。
+//
+// 实现需要自行保证并发安全。
+type LoadBalancer interface {
+	// Pick 根据 key（一致性哈希策略使用，其余策略可忽略）选出一个健康实例。
+	Pick(key string) (*Instance, error)
+	// Release 归还一次 Pick 选中 inst 所占用的资源（目前仅 least_connections
+	// 会据此递减活跃连接数），调用方应在该次代理请求结束后调用。其余策略不
+	// 跟踪每实例状态，Release 是 no-op。
+	Release(inst *Instance)
+	// Update 在 Consul 服务发现结果变化时替换实例集合。
+	Update(instances []*Instance)
+}
+
+// Strategy 标识一种负载均衡策略。
+type Strategy string
+
+// 内置的负载均衡策略。
+const (
+	StrategyRoundRobin         Strategy = "round_robin"
+	StrategyWeightedRoundRobin Strategy = "weighted_round_robin"
+	StrategyLeastConnections   Strategy = "least_connections"
+	StrategyConsistentHash     Strategy = "consistent_hash"
+)
+
+// New 根据策略名称构造对应的 LoadBalancer 实现，默认回退到轮询。
+func New(strategy Strategy, instances []*Instance) LoadBalancer {
+	switch strategy {
+	case StrategyWeightedRoundRobin:
+		return NewWeightedRoundRobin(instances)
+	case StrategyLeastConnections:
+		return NewLeastConnections(instances)
+	case StrategyConsistentHash:
+		return NewConsistentHash(instances)
+	case StrategyRoundRobin:
+		fallthrough
+	default:
+		return NewRoundRobin(instances)
+	}
+}
+
+// healthyInstances 返回 all 中当前健康的实例子集。
+func healthyInstances(all []*Instance) []*Instance {
+	out := make([]*Instance, 0, len(all))
+	for _, inst := range all {
+		if inst.Healthy() {
+			out = append(out, inst)
+		}
+	}
+	return out
+}
@@ -0,0 +1,179 @@
+package loadbalance
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+// refreshInterval 是实例集合从 InstanceFetcher 重新拉取的周期。
+const refreshInterval = 10 * time.Second
+
+// InstanceFetcher 按服务名拉取 Consul（或其他注册中心）中的实例列表。
+type InstanceFetcher func(serviceName string) ([]*Instance, error)
+
+// servicePool 维护单个服务的负载均衡器、健康检查器与熔断器。
+type servicePool struct {
+	lb            LoadBalancer
+	breaker       *CircuitBreaker
+	healthChecker *HealthChecker
+	keySource     *KeySource
+	instances     []*Instance
+	fetchedAt     time.Time
+}
+
+// Manager 按服务名管理一组 servicePool，是 NewReverseProxy 选路的入口。
+type Manager struct {
+	cfg     Config
+	fetcher InstanceFetcher
+	logger  log.Logger
+
+	mu    sync.Mutex
+	pools map[string]*servicePool
+}
+
+// NewManager 创建一个负载均衡管理器，cfg 为空时所有服务使用默认策略
+// （轮询 + 默认健康检查/熔断参数）。
+func NewManager(cfg Config, fetcher InstanceFetcher, logger log.Logger) *Manager {
+	if cfg == nil {
+		cfg = Config{}
+	}
+	return &Manager{
+		cfg:     cfg,
+		fetcher: fetcher,
+		logger:  logger,
+		pools:   make(map[string]*servicePool),
+	}
+}
+
+// Pick 为指定服务选出一个健康实例。当该服务配置为一致性哈希策略时，
+// 会按 ServiceConfig.HashKey 从 r 中提取哈希 key（请求头或路径正则），否则忽略 r
+// 的内容，仅用其路径作为其余策略的（未使用的）默认 key。
+func (m *Manager) Pick(serviceName string, r *http.Request) (*Instance, error) {
+	pool, err := m.poolFor(serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	key := r.URL.Path
+	if pool.keySource != nil {
+		key = pool.keySource.Extract(r)
+	}
+
+	//熔断器拒绝选中的实例时，归还这次 Pick 占用的负载均衡器状态（否则
+	//least_connections 的 activeConns 会永久泄漏），再换一个实例重试，
+	//而不是让整个请求失败；策略对同一个 key 总是选中同一实例时（如一致性
+	//哈希）没有别的实例可以failover，最多重试该服务实例总数那么多次。
+	tried := make(map[string]bool, len(pool.instances))
+	maxAttempts := len(pool.instances)
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		inst, err := pool.lb.Pick(key)
+		if err != nil {
+			return nil, err
+		}
+		if pool.breaker.Allow(inst) {
+			return inst, nil
+		}
+		pool.lb.Release(inst)
+		if tried[inst.ServiceID] {
+			break
+		}
+		tried[inst.ServiceID] = true
+	}
+	return nil, ErrNoHealthyInstance
+}
+
+// RecordResult 把一次请求的成败反馈给对应服务的熔断器，success 为 false
+// 代表上游返回了 5xx 或请求超时/出错。
+func (m *Manager) RecordResult(serviceName string, inst *Instance, success bool) {
+	m.mu.Lock()
+	pool, ok := m.pools[serviceName]
+	m.mu.Unlock()
+	if !ok {
+		return
+	}
+	if success {
+		pool.breaker.RecordSuccess(inst)
+	} else {
+		pool.breaker.RecordFailure(inst)
+	}
+}
+
+// Release 归还一次 Pick 选中 inst 占用的负载均衡器状态（目前仅
+// least_connections 的活跃连接数），调用方应在该次代理请求结束后调用，
+// 与 RecordResult 配对使用。
+func (m *Manager) Release(serviceName string, inst *Instance) {
+	m.mu.Lock()
+	pool, ok := m.pools[serviceName]
+	m.mu.Unlock()
+	if !ok {
+		return
+	}
+	pool.lb.Release(inst)
+}
+
+// poolFor 返回（必要时创建并刷新）指定服务的 servicePool。
+func (m *Manager) poolFor(serviceName string) (*servicePool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pool, ok := m.pools[serviceName]
+	if ok && time.Since(pool.fetchedAt) < refreshInterval {
+		return pool, nil
+	}
+
+	instances, err := m.fetcher(serviceName)
+	if err != nil {
+		if ok {
+			// Consul 查询失败时继续使用旧的实例集合，优于直接报错。
+			return pool, nil
+		}
+		return nil, err
+	}
+
+	svcCfg := m.cfg.ForService(serviceName)
+
+	if ok {
+		pool.instances = instances
+		pool.lb.Update(instances)
+		pool.healthChecker.UpdateInstances(instances)
+		pool.fetchedAt = time.Now()
+		return pool, nil
+	}
+
+	healthCfg := HealthCheckConfig{
+		Path:     svcCfg.HealthCheck.Path,
+		Interval: svcCfg.HealthCheck.Interval.Duration(),
+		Timeout:  svcCfg.HealthCheck.Timeout.Duration(),
+	}
+	breakerCfg := CircuitBreakerConfig{
+		FailureThreshold: svcCfg.CircuitBreaker.FailureThreshold,
+		Cooldown:         svcCfg.CircuitBreaker.Cooldown.Duration(),
+	}
+
+	pool = &servicePool{
+		lb:        New(svcCfg.Strategy, instances),
+		breaker:   NewCircuitBreaker(breakerCfg),
+		instances: instances,
+		fetchedAt: time.Now(),
+	}
+	pool.healthChecker = NewHealthChecker(pool.instances, healthCfg, m.logger)
+	pool.healthChecker.Start()
+
+	if svcCfg.Strategy == StrategyConsistentHash {
+		keySource, err := NewKeySource(svcCfg.HashKey)
+		if err != nil {
+			m.logger.Log("loadbalance", "invalid hash_key config", "serviceName", serviceName, "err", err)
+		} else {
+			pool.keySource = keySource
+		}
+	}
+
+	m.pools[serviceName] = pool
+	return pool, nil
+}
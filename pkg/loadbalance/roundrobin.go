@@ -0,0 +1,40 @@
+package loadbalance
+
+import "sync"
+
+// RoundRobin 按顺序轮流选择健康实例。
+type RoundRobin struct {
+	mu        sync.Mutex
+	instances []*Instance
+	next      int
+}
+
+// NewRoundRobin 创建一个轮询负载均衡器。
+func NewRoundRobin(instances []*Instance) *RoundRobin {
+	return &RoundRobin{instances: instances}
+}
+
+// Pick 实现 LoadBalancer。
+func (r *RoundRobin) Pick(_ string) (*Instance, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	healthy := healthyInstances(r.instances)
+	if len(healthy) == 0 {
+		return nil, ErrNoHealthyInstance
+	}
+
+	inst := healthy[r.next%len(healthy)]
+	r.next++
+	return inst, nil
+}
+
+// Release 实现 LoadBalancer；轮询不跟踪每实例状态，no-op。
+func (r *RoundRobin) Release(_ *Instance) {}
+
+// Update 实现 LoadBalancer。
+func (r *RoundRobin) Update(instances []*Instance) {
+	r.mu.Lock()
+	r.instances = instances
+	r.mu.Unlock()
+}
@@ -0,0 +1,29 @@
+package loadbalance
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// BackendTLSConfig 按 ServiceConfig.TLS 构造拨号后端实例使用的 *tls.Config。
+// 未配置 CACertFile 时使用系统根证书。
+func BackendTLSConfig(cfg ServiceConfig) (*tls.Config, error) {
+	tlsCfg := &tls.Config{InsecureSkipVerify: cfg.TLS.InsecureSkipVerify}
+
+	if cfg.TLS.CACertFile == "" {
+		return tlsCfg, nil
+	}
+
+	pem, err := os.ReadFile(cfg.TLS.CACertFile)
+	if err != nil {
+		return nil, fmt.Errorf("loadbalance: read ca_cert_file %s: %w", cfg.TLS.CACertFile, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("loadbalance: no certificates found in %s", cfg.TLS.CACertFile)
+	}
+	tlsCfg.RootCAs = pool
+	return tlsCfg, nil
+}
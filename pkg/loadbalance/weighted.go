@@ -0,0 +1,50 @@
+package loadbalance
+
+import "sync"
+
+// WeightedRoundRobin 实现 nginx 风格的平滑加权轮询：每次选出 current 权重
+// 游标最大的实例，选中后将其游标减去全部权重之和，从而让权重大的实例更频繁
+// 地被选中，同时保持分布平滑（权重大的实例的选中次数穿插分布在其他实例之间，
+// 而不是集中成一段；权重相等时各实例严格交替）。
+type WeightedRoundRobin struct {
+	mu        sync.Mutex
+	instances []*Instance
+}
+
+// NewWeightedRoundRobin 创建一个加权轮询负载均衡器。
+func NewWeightedRoundRobin(instances []*Instance) *WeightedRoundRobin {
+	return &WeightedRoundRobin{instances: instances}
+}
+
+// Pick 实现 LoadBalancer。
+func (w *WeightedRoundRobin) Pick(_ string) (*Instance, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	healthy := healthyInstances(w.instances)
+	if len(healthy) == 0 {
+		return nil, ErrNoHealthyInstance
+	}
+
+	var total int
+	var best *Instance
+	for _, inst := range healthy {
+		inst.current += inst.Weight
+		total += inst.Weight
+		if best == nil || inst.current > best.current {
+			best = inst
+		}
+	}
+	best.current -= total
+	return best, nil
+}
+
+// Release 实现 LoadBalancer；加权轮询不跟踪每实例状态，no-op。
+func (w *WeightedRoundRobin) Release(_ *Instance) {}
+
+// Update 实现 LoadBalancer。
+func (w *WeightedRoundRobin) Update(instances []*Instance) {
+	w.mu.Lock()
+	w.instances = instances
+	w.mu.Unlock()
+}
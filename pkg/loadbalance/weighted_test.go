@@ -0,0 +1,75 @@
+package loadbalance
+
+import "testing"
+
+func TestWeightedRoundRobinDistributesByWeight(t *testing.T) {
+	a := NewInstance("a", "10.0.0.1", 8080, 5, false)
+	b := NewInstance("b", "10.0.0.2", 8080, 1, false)
+	w := NewWeightedRoundRobin([]*Instance{a, b})
+
+	counts := map[string]int{}
+	const rounds = 60
+	for i := 0; i < rounds; i++ {
+		inst, err := w.Pick("")
+		if err != nil {
+			t.Fatalf("Pick: %v", err)
+		}
+		counts[inst.ServiceID]++
+	}
+
+	// Over enough rounds the ratio should converge to the configured 5:1
+	// weight ratio.
+	if counts["a"] <= counts["b"]*3 {
+		t.Fatalf("counts = %v, want a picked roughly 5x as often as b", counts)
+	}
+	if counts["a"]+counts["b"] != rounds {
+		t.Fatalf("counts sum to %d, want %d", counts["a"]+counts["b"], rounds)
+	}
+}
+
+func TestWeightedRoundRobinIsSmooth(t *testing.T) {
+	// Equal weights must never pick the same instance twice in a row -
+	// that's the "smooth" part of smooth weighted round robin.
+	a := NewInstance("a", "10.0.0.1", 8080, 1, false)
+	b := NewInstance("b", "10.0.0.2", 8080, 1, false)
+	w := NewWeightedRoundRobin([]*Instance{a, b})
+
+	var prev string
+	for i := 0; i < 20; i++ {
+		inst, err := w.Pick("")
+		if err != nil {
+			t.Fatalf("Pick: %v", err)
+		}
+		if inst.ServiceID == prev {
+			t.Fatalf("picked %s twice in a row at iteration %d, want alternation under equal weights", prev, i)
+		}
+		prev = inst.ServiceID
+	}
+}
+
+func TestWeightedRoundRobinSkipsUnhealthyInstances(t *testing.T) {
+	a := NewInstance("a", "10.0.0.1", 8080, 1, false)
+	b := NewInstance("b", "10.0.0.2", 8080, 1, false)
+	w := NewWeightedRoundRobin([]*Instance{a, b})
+
+	a.SetHealthy(false)
+	for i := 0; i < 10; i++ {
+		inst, err := w.Pick("")
+		if err != nil {
+			t.Fatalf("Pick: %v", err)
+		}
+		if inst.ServiceID != "b" {
+			t.Fatalf("Pick = %s, want only healthy instance b", inst.ServiceID)
+		}
+	}
+}
+
+func TestWeightedRoundRobinNoHealthyInstances(t *testing.T) {
+	a := NewInstance("a", "10.0.0.1", 8080, 1, false)
+	a.SetHealthy(false)
+	w := NewWeightedRoundRobin([]*Instance{a})
+
+	if _, err := w.Pick(""); err != ErrNoHealthyInstance {
+		t.Fatalf("Pick = %v, want ErrNoHealthyInstance", err)
+	}
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+// NewAccessLog 构造一个通过 go-kit log.Logger 输出结构化访问日志的中间件，
+// 记录方法、路径、状态码与耗时。
+func NewAccessLog(logger log.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := newStatusRecorder(w)
+
+			next.ServeHTTP(rec, r)
+
+			logger.Log(
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", rec.status,
+				"duration", time.Since(start),
+				"remote_addr", r.RemoteAddr,
+			)
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Config 是中间件链的整体配置：各内置中间件自身的参数，以及按路由前缀
+// 启用哪些中间件、以什么顺序执行。
+type Config struct {
+	JWT       JWTConfig       `json:"jwt"`
+	RateLimit RateLimitConfig `json:"rate_limit"`
+	Routes    []RouteConfig   `json:"routes"`
+}
+
+// LoadConfig 从磁盘读取 JSON 格式的中间件配置文件。
+func LoadConfig(path string) (Config, error) {
+	var cfg Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("middleware: read config %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("middleware: parse config %s: %w", path, err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// jwksDoc 是 JWKS 端点返回文档的最小子集。
+type jwksDoc struct {
+	Keys []jwksKey `json:"keys"`
+}
+
+type jwksKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// jwksCache 周期性地从 JWKS 端点拉取 RSA 公钥并按 kid 缓存，供 RS256
+// 校验使用，避免每次请求都发起网络调用。
+type jwksCache struct {
+	url string
+
+	mu   sync.RWMutex
+	keys map[string]*rsa.PublicKey
+}
+
+// newJWKSCache 创建缓存并立即拉取一次，随后按 refresh 周期后台刷新。
+func newJWKSCache(url string, refresh time.Duration) *jwksCache {
+	c := &jwksCache{url: url, keys: make(map[string]*rsa.PublicKey)}
+	c.refresh()
+	go func() {
+		ticker := time.NewTicker(refresh)
+		defer ticker.Stop()
+		for range ticker.C {
+			c.refresh()
+		}
+	}()
+	return c
+}
+
+// Lookup 按 kid 返回缓存的 RSA 公钥。
+func (c *jwksCache) Lookup(kid string) (*rsa.PublicKey, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	key, ok := c.keys[kid]
+	return key, ok
+}
+
+// refresh 拉取并解析 JWKS 文档，失败时保留上一次的缓存内容。
+func (c *jwksCache) refresh() {
+	keys, err := fetchJWKS(c.url)
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	c.keys = keys
+	c.mu.Unlock()
+}
+
+// fetchJWKS 请求 JWKS 端点并把每个 RSA 密钥解析为 *rsa.PublicKey。
+func fetchJWKS(url string) (map[string]*rsa.PublicKey, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var doc jwksDoc
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		pub, err := rsaPublicKey(k.N, k.E)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+	return keys, nil
+}
+
+// rsaPublicKey 把 JWKS 中 base64url 编码的模数/指数还原为 *rsa.PublicKey。
+func rsaPublicKey(nEnc, eEnc string) (*rsa.PublicKey, error) {
+	nBytes, err := base64.RawURLEncoding.DecodeString(nEnc)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: decode modulus: %w", err)
+	}
+	eBytes, err := base64.RawURLEncoding.DecodeString(eEnc)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: decode exponent: %w", err)
+	}
+
+	e := 0
+	for _, b := range eBytes {
+		e = e<<8 + int(b)
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(nBytes),
+		E: e,
+	}, nil
+}
@@ -0,0 +1,50 @@
+// Package middleware 提供网关入口处可组合的 HTTP 中间件链，包括 JWT 鉴权、
+// 限流、Prometheus 指标导出与访问日志，并支持按路由前缀配置启用顺序。
+package middleware
+
+import "net/http"
+
+// Middleware 包装一个 http.Handler，生成附加了额外行为的新 http.Handler。
+type Middleware func(http.Handler) http.Handler
+
+// Chain 按给定顺序组合多个 Middleware：mws[0] 最先执行。
+func Chain(mws ...Middleware) Middleware {
+	return func(final http.Handler) http.Handler {
+		for i := len(mws) - 1; i >= 0; i-- {
+			final = mws[i](final)
+		}
+		return final
+	}
+}
+
+// statusRecorder 包装 http.ResponseWriter 以捕获最终写出的状态码，供
+// 访问日志和指标中间件使用。
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func newStatusRecorder(w http.ResponseWriter) *statusRecorder {
+	return &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// firstPathSegment 返回请求路径的第一段，对应网关约定中的 Consul 服务名。
+func firstPathSegment(path string) string {
+	if len(path) == 0 {
+		return ""
+	}
+	if path[0] == '/' {
+		path = path[1:]
+	}
+	for i := 0; i < len(path); i++ {
+		if path[i] == '/' {
+			return path[:i]
+		}
+	}
+	return path
+}
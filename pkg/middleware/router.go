@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// RouteConfig 为某个路径前缀声明需要启用的中间件及其顺序。
+type RouteConfig struct {
+	// PathPrefix 是要匹配的请求路径前缀，如 "/user/"。
+	PathPrefix string `json:"path_prefix"`
+	// Middlewares 是按执行顺序启用的中间件名称，须在 Registry 中注册，
+	// 如 ["access_log", "rate_limit", "jwt_auth"]。
+	Middlewares []string `json:"middlewares"`
+}
+
+// Registry 将中间件名称映射到其实现，供配置文件按名引用。
+type Registry map[string]Middleware
+
+// compiledRoute 是为某个前缀预先组装好的处理链。
+type compiledRoute struct {
+	prefix  string
+	handler http.Handler
+}
+
+// NewRouter 依据 routes 为匹配到的最长路径前缀应用对应的中间件链，未匹配
+// 任何前缀的请求直接交给 next 处理。这使得操作者可以只在 /user/* 上启用
+// JWT 鉴权而 /public/* 保持开放。
+func NewRouter(routes []RouteConfig, registry Registry) Middleware {
+	return func(next http.Handler) http.Handler {
+		compiled := make([]compiledRoute, 0, len(routes))
+		for _, rt := range routes {
+			mws := make([]Middleware, 0, len(rt.Middlewares))
+			for _, name := range rt.Middlewares {
+				if mw, ok := registry[name]; ok {
+					mws = append(mws, mw)
+				}
+			}
+			compiled = append(compiled, compiledRoute{prefix: rt.PathPrefix, handler: Chain(mws...)(next)})
+		}
+
+		// 前缀越长优先级越高，避免 "/" 这样的宽泛规则掩盖更具体的规则。
+		sort.SliceStable(compiled, func(i, j int) bool {
+			return len(compiled[i].prefix) > len(compiled[j].prefix)
+		})
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, rt := range compiled {
+				if strings.HasPrefix(r.URL.Path, rt.prefix) {
+					rt.handler.ServeHTTP(w, r)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
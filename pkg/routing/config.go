@@ -0,0 +1,80 @@
+// Package routing 实现网关的声明式路由规则表：按 match 条件挑选规则，
+// 将请求重写并转发到一个 Consul 服务或一组静态 URL。
+package routing
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/tocgis/api-gateway/pkg/jsonutil"
+	"github.com/tocgis/api-gateway/pkg/transform"
+)
+
+// MatchConfig 描述一条规则的匹配条件，留空的字段不参与匹配。
+type MatchConfig struct {
+	// PathPrefix 按前缀匹配 r.URL.Path。
+	PathPrefix string `json:"path_prefix"`
+	// PathRegex 按正则匹配 r.URL.Path，优先级高于 PathPrefix。
+	PathRegex string `json:"path_regex"`
+	// Host 精确匹配请求的 Host 头。
+	Host string `json:"host"`
+	// Methods 限定允许的 HTTP 方法，留空表示不限制。
+	Methods []string `json:"methods"`
+}
+
+// TargetConfig 描述规则匹配后转发到哪里：Service 非空时查询 Consul，
+// 否则在 StaticURLs 中轮询选择。
+type TargetConfig struct {
+	Service    string   `json:"service"`
+	StaticURLs []string `json:"static_urls"`
+}
+
+// RewriteConfig 类似 nginx 的 rewrite 指令：用 PathRegex 匹配原始路径，
+// 用 Replacement 生成新路径（支持 Go regexp 的 $1/${1} 捕获组语法）。
+type RewriteConfig struct {
+	PathRegex   string `json:"path_regex"`
+	Replacement string `json:"replacement"`
+}
+
+// HeaderOpsConfig 描述请求/响应头的增删改操作。
+type HeaderOpsConfig struct {
+	SetRequest     map[string]string `json:"set_request"`
+	AddRequest     map[string]string `json:"add_request"`
+	RemoveRequest  []string          `json:"remove_request"`
+	SetResponse    map[string]string `json:"set_response"`
+	AddResponse    map[string]string `json:"add_response"`
+	RemoveResponse []string          `json:"remove_response"`
+}
+
+// RuleConfig 是规则表中的一条规则。
+type RuleConfig struct {
+	Match   MatchConfig     `json:"match"`
+	Target  TargetConfig    `json:"target"`
+	Rewrite RewriteConfig   `json:"rewrite"`
+	Headers HeaderOpsConfig `json:"headers"`
+	// Transforms 是命中该规则的响应依次经过的 body 改写插件链，留空表示
+	// 不改写响应体（不解码/不重新编码，原样转发）。
+	Transforms []transform.Config `json:"transforms"`
+	// Timeout 覆盖该规则命中请求的默认超时时间（可读的 time.ParseDuration
+	// 字符串，如 "5s"），零值表示使用网关默认值。
+	Timeout jsonutil.Duration `json:"timeout"`
+}
+
+// Config 是整张路由规则表，规则按数组顺序匹配，第一条命中的规则生效。
+type Config struct {
+	Rules []RuleConfig `json:"rules"`
+}
+
+// LoadConfig 从磁盘读取 JSON 格式的路由规则表。
+func LoadConfig(path string) (Config, error) {
+	var cfg Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("routing: read config %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("routing: parse config %s: %w", path, err)
+	}
+	return cfg, nil
+}
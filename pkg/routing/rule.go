@@ -0,0 +1,152 @@
+package routing
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/tocgis/api-gateway/pkg/transform"
+)
+
+// Rule 是编译后的 RuleConfig：正则已预编译、静态 URL 已解析，可安全地
+// 被多个请求并发复用。
+type Rule struct {
+	cfg RuleConfig
+
+	matchPathRegex   *regexp.Regexp
+	rewritePathRegex *regexp.Regexp
+	staticURLs       []*url.URL
+
+	// responseTransform 是命中该规则的响应依次经过的 body 改写插件链。
+	responseTransform *transform.Chain
+
+	// roundRobin 是静态 URL 列表轮询使用的游标。
+	roundRobin uint64
+}
+
+// compileRule 编译单条规则，正则或静态 URL 不合法时返回错误。
+func compileRule(cfg RuleConfig) (*Rule, error) {
+	r := &Rule{cfg: cfg}
+
+	if cfg.Match.PathRegex != "" {
+		re, err := regexp.Compile(cfg.Match.PathRegex)
+		if err != nil {
+			return nil, fmt.Errorf("routing: invalid match.path_regex %q: %w", cfg.Match.PathRegex, err)
+		}
+		r.matchPathRegex = re
+	}
+
+	if cfg.Rewrite.PathRegex != "" {
+		re, err := regexp.Compile(cfg.Rewrite.PathRegex)
+		if err != nil {
+			return nil, fmt.Errorf("routing: invalid rewrite.path_regex %q: %w", cfg.Rewrite.PathRegex, err)
+		}
+		r.rewritePathRegex = re
+	}
+
+	for _, raw := range cfg.Target.StaticURLs {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("routing: invalid target static url %q: %w", raw, err)
+		}
+		r.staticURLs = append(r.staticURLs, u)
+	}
+
+	chain, err := transform.NewChain(cfg.Transforms)
+	if err != nil {
+		return nil, fmt.Errorf("routing: invalid transforms: %w", err)
+	}
+	r.responseTransform = chain
+
+	return r, nil
+}
+
+// Matches 判断请求是否命中该规则的 match 条件。
+func (r *Rule) Matches(req *http.Request) bool {
+	if len(r.cfg.Match.Methods) > 0 && !containsMethod(r.cfg.Match.Methods, req.Method) {
+		return false
+	}
+	if r.cfg.Match.Host != "" && req.Host != r.cfg.Match.Host {
+		return false
+	}
+	if r.matchPathRegex != nil {
+		return r.matchPathRegex.MatchString(req.URL.Path)
+	}
+	if r.cfg.Match.PathPrefix != "" {
+		return strings.HasPrefix(req.URL.Path, r.cfg.Match.PathPrefix)
+	}
+	return true
+}
+
+// Service 返回该规则转发到的 Consul 服务名，静态 URL 规则返回空字符串。
+func (r *Rule) Service() string {
+	return r.cfg.Target.Service
+}
+
+// Timeout 返回该规则覆盖的超时时间，零值表示未覆盖。
+func (r *Rule) Timeout() time.Duration {
+	return r.cfg.Timeout.Duration()
+}
+
+// NextStaticURL 在配置的静态 URL 列表中轮询返回下一个，列表为空时返回 nil。
+func (r *Rule) NextStaticURL() *url.URL {
+	if len(r.staticURLs) == 0 {
+		return nil
+	}
+	idx := atomic.AddUint64(&r.roundRobin, 1) - 1
+	return r.staticURLs[idx%uint64(len(r.staticURLs))]
+}
+
+// RewritePath 应用 nginx 风格的正则重写，未配置 Rewrite 时原样返回 path。
+func (r *Rule) RewritePath(path string) string {
+	if r.rewritePathRegex == nil {
+		return path
+	}
+	return r.rewritePathRegex.ReplaceAllString(path, r.cfg.Rewrite.Replacement)
+}
+
+// ApplyRequestHeaders 按配置对请求头执行 set/add/remove。
+func (r *Rule) ApplyRequestHeaders(h http.Header) {
+	for k, v := range r.cfg.Headers.SetRequest {
+		h.Set(k, v)
+	}
+	for k, v := range r.cfg.Headers.AddRequest {
+		h.Add(k, v)
+	}
+	for _, k := range r.cfg.Headers.RemoveRequest {
+		h.Del(k)
+	}
+}
+
+// ResponseTransform 返回该规则配置的响应 body 改写插件链；未配置
+// transforms 时返回一个空链，Chain.Apply 对空链直接跳过。
+func (r *Rule) ResponseTransform() *transform.Chain {
+	return r.responseTransform
+}
+
+// ApplyResponseHeaders 按配置对响应头执行 set/add/remove。
+func (r *Rule) ApplyResponseHeaders(h http.Header) {
+	for k, v := range r.cfg.Headers.SetResponse {
+		h.Set(k, v)
+	}
+	for k, v := range r.cfg.Headers.AddResponse {
+		h.Add(k, v)
+	}
+	for _, k := range r.cfg.Headers.RemoveResponse {
+		h.Del(k)
+	}
+}
+
+// containsMethod 判断 method 是否（大小写不敏感）出现在 methods 中。
+func containsMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package routing
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/go-kit/kit/log"
+)
+
+// Table 是一张可并发读取、支持原子热替换的路由规则表。
+type Table struct {
+	rules  atomic.Value // []*Rule
+	logger log.Logger
+}
+
+// NewTable 编译 cfg 中的规则并构造一张规则表。
+func NewTable(cfg Config, logger log.Logger) (*Table, error) {
+	rules, err := compileRules(cfg)
+	if err != nil {
+		return nil, err
+	}
+	t := &Table{logger: logger}
+	t.rules.Store(rules)
+	return t, nil
+}
+
+// compileRules 编译 cfg 中的每条规则。
+func compileRules(cfg Config) ([]*Rule, error) {
+	rules := make([]*Rule, 0, len(cfg.Rules))
+	for _, rc := range cfg.Rules {
+		rule, err := compileRule(rc)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// Match 返回第一条命中 req 的规则，没有任何规则命中时返回 nil。
+func (t *Table) Match(req *http.Request) *Rule {
+	for _, r := range t.rules.Load().([]*Rule) {
+		if r.Matches(req) {
+			return r
+		}
+	}
+	return nil
+}
+
+// Empty 报告规则表当前是否为空，用于在未配置规则表时保留旧的转发约定。
+func (t *Table) Empty() bool {
+	return len(t.rules.Load().([]*Rule)) == 0
+}
+
+// ReloadFromFile 重新读取并编译 path 指向的规则表，原子替换当前生效的规则，
+// 解析失败时保留旧规则不变。由 SIGHUP 处理器调用。
+func (t *Table) ReloadFromFile(path string) error {
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return err
+	}
+	rules, err := compileRules(cfg)
+	if err != nil {
+		return err
+	}
+	t.rules.Store(rules)
+	t.logger.Log("routing", "reloaded from file", "path", path, "rules", len(rules))
+	return nil
+}
+
+// replace 原子替换当前规则表，供 Consul KV watcher 使用。
+func (t *Table) replace(rules []*Rule) {
+	t.rules.Store(rules)
+}
@@ -0,0 +1,50 @@
+package routing
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// WatchConsulKV 长轮询 Consul KV 中 key 处的路由规则表 JSON，变化时原子
+// 替换 t 的内容，直到 stopCh 关闭。网络错误后会退避重试，不影响已生效的
+// 规则表继续提供服务。
+func WatchConsulKV(client *api.Client, key string, t *Table, stopCh <-chan struct{}) {
+	var lastIndex uint64
+	for {
+		select {
+		case <-stopCh:
+			return
+		default:
+		}
+
+		pair, meta, err := client.KV().Get(key, &api.QueryOptions{
+			WaitIndex: lastIndex,
+			WaitTime:  5 * time.Minute,
+		})
+		if err != nil {
+			t.logger.Log("routing", "consul kv watch error", "key", key, "err", err.Error())
+			time.Sleep(5 * time.Second)
+			continue
+		}
+		if pair == nil {
+			time.Sleep(5 * time.Second)
+			continue
+		}
+		lastIndex = meta.LastIndex
+
+		var cfg Config
+		if err := json.Unmarshal(pair.Value, &cfg); err != nil {
+			t.logger.Log("routing", "consul kv invalid config", "key", key, "err", err.Error())
+			continue
+		}
+		rules, err := compileRules(cfg)
+		if err != nil {
+			t.logger.Log("routing", "consul kv invalid rules", "key", key, "err", err.Error())
+			continue
+		}
+		t.replace(rules)
+		t.logger.Log("routing", "reloaded from consul kv", "key", key, "rules", len(rules))
+	}
+}
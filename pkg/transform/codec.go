@@ -0,0 +1,62 @@
+package transform
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// decode decompresses raw per the response's Content-Encoding. Unknown or
+// empty encodings (including "identity") are returned unchanged.
+func decode(encoding string, raw []byte) ([]byte, error) {
+	switch strings.ToLower(encoding) {
+	case "gzip":
+		r, err := gzip.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, fmt.Errorf("transform: gzip decode: %w", err)
+		}
+		defer r.Close()
+		return ioutil.ReadAll(r)
+	case "deflate":
+		r := flate.NewReader(bytes.NewReader(raw))
+		defer r.Close()
+		return ioutil.ReadAll(r)
+	default:
+		return raw, nil
+	}
+}
+
+// encode recompresses body with the same encoding decode was given, so the
+// response leaves the gateway compressed the same way the backend sent it.
+func encode(encoding string, body []byte) ([]byte, error) {
+	switch strings.ToLower(encoding) {
+	case "gzip":
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(body); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case "deflate":
+		var buf bytes.Buffer
+		w, err := flate.NewWriter(&buf, flate.DefaultCompression)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(body); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return body, nil
+	}
+}
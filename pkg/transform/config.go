@@ -0,0 +1,65 @@
+package transform
+
+import "fmt"
+
+// Config is one configured pipeline stage; Type selects which of the other
+// fields apply. A route's transform chain is simply []Config, compiled once
+// by NewChain and reused for every response.
+type Config struct {
+	Type string `json:"type"`
+
+	// Regex is used by Type == "regex_replace".
+	Regex RegexConfig `json:"regex,omitempty"`
+	// JSONField is used by Type == "json_inject" and "json_redact".
+	JSONField JSONFieldConfig `json:"json_field,omitempty"`
+	// ScrubFields is used by Type == "scrub_sensitive": JSON object field
+	// names (case-insensitive, matched anywhere in the document) whose
+	// values are replaced with a fixed mask before forwarding the response.
+	ScrubFields []string `json:"scrub_fields,omitempty"`
+	// HeadScript is used by Type == "head_script_inject": raw HTML inserted
+	// just before the document's closing </head> tag.
+	HeadScript string `json:"head_script,omitempty"`
+	// Envelope is used by Type == "envelope".
+	Envelope EnvelopeConfig `json:"envelope,omitempty"`
+}
+
+// RegexConfig configures a regex_replace stage: every match of Pattern in
+// the raw body is replaced with Replacement (Go regexp $1/${1} syntax).
+type RegexConfig struct {
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+}
+
+// JSONFieldConfig configures a json_inject/json_redact stage. Path is an
+// RFC 6901 JSON Pointer (e.g. "/data/user/token") into the response body.
+type JSONFieldConfig struct {
+	Path string `json:"path"`
+	// Value is written at Path; only meaningful for json_inject.
+	Value interface{} `json:"value,omitempty"`
+}
+
+// EnvelopeConfig configures the envelope stage's fixed code/msg.
+type EnvelopeConfig struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+// build compiles a single Config entry into a Transformer.
+func build(cfg Config) (Transformer, error) {
+	switch cfg.Type {
+	case "regex_replace":
+		return newRegexReplace(cfg.Regex)
+	case "json_inject":
+		return newJSONInject(cfg.JSONField)
+	case "json_redact":
+		return newJSONRedact(cfg.JSONField)
+	case "scrub_sensitive":
+		return newSensitiveScrub(cfg.ScrubFields), nil
+	case "head_script_inject":
+		return newHeadScriptInject(cfg.HeadScript), nil
+	case "envelope":
+		return newEnvelope(cfg.Envelope), nil
+	default:
+		return nil, fmt.Errorf("transform: unknown transformer type %q", cfg.Type)
+	}
+}
@@ -0,0 +1,41 @@
+package transform
+
+import "encoding/json"
+
+// ResponseMap is the gateway's uniform response envelope.
+type ResponseMap struct {
+	Code int         `json:"code"`
+	Msg  string      `json:"msg"`
+	Data interface{} `json:"data"`
+}
+
+// envelope wraps a response body in ResponseMap. A JSON body is decoded into
+// Data as-is; anything else (or a JSON body that fails to decode) is carried
+// as a string so non-JSON upstreams aren't mangled.
+type envelope struct {
+	cfg EnvelopeConfig
+}
+
+func newEnvelope(cfg EnvelopeConfig) Transformer {
+	return &envelope{cfg: cfg}
+}
+
+func (e *envelope) Transform(ctx *Context) error {
+	var data interface{}
+	if isJSON(ctx) && len(ctx.Body) > 0 {
+		if err := json.Unmarshal(ctx.Body, &data); err != nil {
+			data = string(ctx.Body)
+		}
+	} else {
+		data = string(ctx.Body)
+	}
+
+	wrapped, err := json.Marshal(ResponseMap{Code: e.cfg.Code, Msg: e.cfg.Msg, Data: data})
+	if err != nil {
+		return err
+	}
+	ctx.Body = wrapped
+	ctx.ContentType = "application/json"
+	ctx.Header.Set("Content-Type", "application/json")
+	return nil
+}
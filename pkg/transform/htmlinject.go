@@ -0,0 +1,34 @@
+package transform
+
+import (
+	"bytes"
+	"strings"
+)
+
+// headScriptInject inserts the configured HTML (typically a <script> block)
+// as-is just before the document's closing </head> tag, found
+// case-insensitively. Responses with no </head> (or
+// that aren't HTML) are left untouched.
+type headScriptInject struct {
+	script []byte
+}
+
+func newHeadScriptInject(script string) Transformer {
+	return &headScriptInject{script: []byte(script)}
+}
+
+func (t *headScriptInject) Transform(ctx *Context) error {
+	if len(t.script) == 0 || !strings.Contains(strings.ToLower(ctx.ContentType), "html") {
+		return nil
+	}
+	idx := bytes.Index(bytes.ToLower(ctx.Body), []byte("</head>"))
+	if idx < 0 {
+		return nil
+	}
+	out := make([]byte, 0, len(ctx.Body)+len(t.script))
+	out = append(out, ctx.Body[:idx]...)
+	out = append(out, t.script...)
+	out = append(out, ctx.Body[idx:]...)
+	ctx.Body = out
+	return nil
+}
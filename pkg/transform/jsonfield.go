@@ -0,0 +1,149 @@
+package transform
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// jsonFieldOp injects or redacts a single field in a JSON response body,
+// addressed by an RFC 6901 JSON Pointer (e.g. "/data/user/token").
+type jsonFieldOp struct {
+	segments []string
+	inject   bool
+	value    interface{}
+}
+
+func newJSONInject(cfg JSONFieldConfig) (Transformer, error) {
+	segments, err := splitPointer(cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+	return &jsonFieldOp{segments: segments, inject: true, value: cfg.Value}, nil
+}
+
+func newJSONRedact(cfg JSONFieldConfig) (Transformer, error) {
+	segments, err := splitPointer(cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+	return &jsonFieldOp{segments: segments, inject: false}, nil
+}
+
+// Transform is intentionally lenient: a non-JSON or malformed body, or a
+// path that doesn't resolve, leaves the response untouched rather than
+// failing the request over a cosmetic rewrite.
+func (t *jsonFieldOp) Transform(ctx *Context) error {
+	if !isJSON(ctx) || len(ctx.Body) == 0 {
+		return nil
+	}
+	var doc interface{}
+	if err := json.Unmarshal(ctx.Body, &doc); err != nil {
+		return nil
+	}
+
+	if t.inject {
+		if err := setJSONPointer(doc, t.segments, t.value); err != nil {
+			return nil
+		}
+	} else {
+		deleteJSONPointer(doc, t.segments)
+	}
+
+	encoded, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	ctx.Body = encoded
+	return nil
+}
+
+// splitPointer parses an RFC 6901 JSON Pointer into its unescaped segments.
+func splitPointer(pointer string) ([]string, error) {
+	if pointer == "" || pointer == "/" {
+		return nil, fmt.Errorf("transform: empty json pointer")
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("transform: json pointer %q must start with '/'", pointer)
+	}
+	raw := strings.Split(pointer[1:], "/")
+	segments := make([]string, len(raw))
+	for i, s := range raw {
+		s = strings.ReplaceAll(s, "~1", "/")
+		s = strings.ReplaceAll(s, "~0", "~")
+		segments[i] = s
+	}
+	return segments, nil
+}
+
+// resolveParent walks all but the last segment and returns the container
+// holding the final segment, plus that segment's key/index.
+func resolveParent(doc interface{}, segments []string) (parent interface{}, key string, ok bool) {
+	cur := doc
+	for _, seg := range segments[:len(segments)-1] {
+		switch v := cur.(type) {
+		case map[string]interface{}:
+			next, present := v[seg]
+			if !present {
+				return nil, "", false
+			}
+			cur = next
+		case []interface{}:
+			idx, err := strconv.Atoi(seg)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil, "", false
+			}
+			cur = v[idx]
+		default:
+			return nil, "", false
+		}
+	}
+	return cur, segments[len(segments)-1], true
+}
+
+// setJSONPointer sets the value at segments, requiring every intermediate
+// segment to already resolve to a map or slice (it never creates new
+// containers along the way).
+func setJSONPointer(doc interface{}, segments []string, value interface{}) error {
+	parent, key, ok := resolveParent(doc, segments)
+	if !ok {
+		return fmt.Errorf("transform: json pointer path not found")
+	}
+	switch p := parent.(type) {
+	case map[string]interface{}:
+		p[key] = value
+		return nil
+	case []interface{}:
+		idx, err := strconv.Atoi(key)
+		if err != nil || idx < 0 || idx >= len(p) {
+			return fmt.Errorf("transform: invalid array index %q", key)
+		}
+		p[idx] = value
+		return nil
+	default:
+		return fmt.Errorf("transform: json pointer path not found")
+	}
+}
+
+// deleteJSONPointer removes the field addressed by segments, if present. A
+// missing path is not an error: redacting an absent field is a no-op.
+func deleteJSONPointer(doc interface{}, segments []string) {
+	parent, key, ok := resolveParent(doc, segments)
+	if !ok {
+		return
+	}
+	switch p := parent.(type) {
+	case map[string]interface{}:
+		delete(p, key)
+	case []interface{}:
+		if idx, err := strconv.Atoi(key); err == nil && idx >= 0 && idx < len(p) {
+			p[idx] = nil
+		}
+	}
+}
+
+// isJSON reports whether ctx's Content-Type looks like JSON.
+func isJSON(ctx *Context) bool {
+	return strings.Contains(strings.ToLower(ctx.ContentType), "json")
+}
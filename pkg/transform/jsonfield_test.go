@@ -0,0 +1,134 @@
+package transform
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSplitPointer(t *testing.T) {
+	cases := []struct {
+		name    string
+		pointer string
+		want    []string
+		wantErr bool
+	}{
+		{name: "simple", pointer: "/data/user", want: []string{"data", "user"}},
+		{name: "array index", pointer: "/items/0/id", want: []string{"items", "0", "id"}},
+		{name: "tilde escape", pointer: "/a~0b", want: []string{"a~b"}},
+		{name: "slash escape", pointer: "/a~1b", want: []string{"a/b"}},
+		{name: "escape order ~01 decodes to ~1", pointer: "/a~01", want: []string{"a~1"}},
+		{name: "empty", pointer: "", wantErr: true},
+		{name: "root only", pointer: "/", wantErr: true},
+		{name: "missing leading slash", pointer: "data/user", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := splitPointer(tc.pointer)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("splitPointer(%q): expected error, got %v", tc.pointer, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("splitPointer(%q): unexpected error: %v", tc.pointer, err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("splitPointer(%q) = %v, want %v", tc.pointer, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSetJSONPointer(t *testing.T) {
+	t.Run("sets existing map key", func(t *testing.T) {
+		var doc interface{}
+		mustUnmarshal(t, `{"data":{"token":"old"}}`, &doc)
+
+		if err := setJSONPointer(doc, []string{"data", "token"}, "new"); err != nil {
+			t.Fatalf("setJSONPointer: %v", err)
+		}
+		got := doc.(map[string]interface{})["data"].(map[string]interface{})["token"]
+		if got != "new" {
+			t.Fatalf("token = %v, want %q", got, "new")
+		}
+	})
+
+	t.Run("sets array element by index", func(t *testing.T) {
+		var doc interface{}
+		mustUnmarshal(t, `{"items":["a","b","c"]}`, &doc)
+
+		if err := setJSONPointer(doc, []string{"items", "1"}, "z"); err != nil {
+			t.Fatalf("setJSONPointer: %v", err)
+		}
+		got := doc.(map[string]interface{})["items"].([]interface{})[1]
+		if got != "z" {
+			t.Fatalf("items[1] = %v, want %q", got, "z")
+		}
+	})
+
+	t.Run("errors on missing intermediate segment", func(t *testing.T) {
+		var doc interface{}
+		mustUnmarshal(t, `{"data":{}}`, &doc)
+
+		if err := setJSONPointer(doc, []string{"data", "user", "id"}, 1); err == nil {
+			t.Fatalf("expected error for unresolvable path")
+		}
+	})
+
+	t.Run("errors on out-of-range array index", func(t *testing.T) {
+		var doc interface{}
+		mustUnmarshal(t, `{"items":["a"]}`, &doc)
+
+		if err := setJSONPointer(doc, []string{"items", "5"}, "z"); err == nil {
+			t.Fatalf("expected error for out-of-range index")
+		}
+	})
+}
+
+func TestDeleteJSONPointer(t *testing.T) {
+	t.Run("deletes existing map key", func(t *testing.T) {
+		var doc interface{}
+		mustUnmarshal(t, `{"data":{"token":"secret","id":1}}`, &doc)
+
+		deleteJSONPointer(doc, []string{"data", "token"})
+		data := doc.(map[string]interface{})["data"].(map[string]interface{})
+		if _, ok := data["token"]; ok {
+			t.Fatalf("token still present after delete")
+		}
+		if data["id"] != float64(1) {
+			t.Fatalf("unrelated key id was mutated: %v", data["id"])
+		}
+	})
+
+	t.Run("missing path is a no-op", func(t *testing.T) {
+		var doc interface{}
+		mustUnmarshal(t, `{"data":{}}`, &doc)
+
+		deleteJSONPointer(doc, []string{"data", "missing"})
+		data := doc.(map[string]interface{})["data"].(map[string]interface{})
+		if len(data) != 0 {
+			t.Fatalf("expected no-op, got %v", data)
+		}
+	})
+
+	t.Run("array element is nulled, not removed", func(t *testing.T) {
+		var doc interface{}
+		mustUnmarshal(t, `{"items":["a","b"]}`, &doc)
+
+		deleteJSONPointer(doc, []string{"items", "0"})
+		items := doc.(map[string]interface{})["items"].([]interface{})
+		if len(items) != 2 || items[0] != nil {
+			t.Fatalf("items = %v, want [nil, \"b\"]", items)
+		}
+	})
+}
+
+func mustUnmarshal(t *testing.T, raw string, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal([]byte(raw), v); err != nil {
+		t.Fatalf("json.Unmarshal(%q): %v", raw, err)
+	}
+}
@@ -0,0 +1,24 @@
+package transform
+
+import "regexp"
+
+// regexReplace runs regexp.ReplaceAll over the raw response body; useful for
+// lightweight textual substitution, e.g. rewriting absolute backend URLs
+// embedded in an HTML/JS payload.
+type regexReplace struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+func newRegexReplace(cfg RegexConfig) (Transformer, error) {
+	re, err := regexp.Compile(cfg.Pattern)
+	if err != nil {
+		return nil, err
+	}
+	return &regexReplace{pattern: re, replacement: cfg.Replacement}, nil
+}
+
+func (t *regexReplace) Transform(ctx *Context) error {
+	ctx.Body = t.pattern.ReplaceAll(ctx.Body, []byte(t.replacement))
+	return nil
+}
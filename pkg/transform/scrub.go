@@ -0,0 +1,60 @@
+package transform
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// sensitiveMask replaces a scrubbed field's value in the response.
+const sensitiveMask = "***"
+
+// sensitiveScrub walks an upstream JSON response and replaces the value of
+// any object field whose name matches (case-insensitively) one of fields,
+// wherever it occurs in the document. Unlike jsonFieldOp it doesn't need the
+// field's exact path, which is what makes it a useful blanket safety net for
+// things like passwords and tokens that should never leave the gateway.
+type sensitiveScrub struct {
+	fields map[string]struct{}
+}
+
+func newSensitiveScrub(fields []string) Transformer {
+	set := make(map[string]struct{}, len(fields))
+	for _, f := range fields {
+		set[strings.ToLower(f)] = struct{}{}
+	}
+	return &sensitiveScrub{fields: set}
+}
+
+func (t *sensitiveScrub) Transform(ctx *Context) error {
+	if !isJSON(ctx) || len(t.fields) == 0 || len(ctx.Body) == 0 {
+		return nil
+	}
+	var doc interface{}
+	if err := json.Unmarshal(ctx.Body, &doc); err != nil {
+		return nil
+	}
+	t.scrub(doc)
+	encoded, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	ctx.Body = encoded
+	return nil
+}
+
+func (t *sensitiveScrub) scrub(node interface{}) {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for key, val := range v {
+			if _, sensitive := t.fields[strings.ToLower(key)]; sensitive {
+				v[key] = sensitiveMask
+				continue
+			}
+			t.scrub(val)
+		}
+	case []interface{}:
+		for _, item := range v {
+			t.scrub(item)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Package transform implements the gateway's response-body rewrite pipeline:
+// a route's configured chain of Transformer plugins runs over the (possibly
+// gzip/deflate-encoded) upstream body, then the body is re-encoded and
+// Content-Length recomputed before the response leaves the gateway.
+package transform
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+)
+
+// Context is the mutable state a Transformer operates on. Body always holds
+// the fully decoded (decompressed) payload; Header is the response's header,
+// with Content-Encoding left untouched for the chain to inspect but not act
+// on directly - Chain.Apply handles re-encoding once every stage has run.
+type Context struct {
+	Header      http.Header
+	ContentType string
+	Body        []byte
+}
+
+// Transformer rewrites a decoded response body, mutating ctx in place.
+type Transformer interface {
+	Transform(ctx *Context) error
+}
+
+// Chain runs a fixed, ordered list of Transformers over a response body.
+type Chain struct {
+	transformers []Transformer
+}
+
+// NewChain compiles cfgs, in order, into a ready-to-run Chain.
+func NewChain(cfgs []Config) (*Chain, error) {
+	c := &Chain{transformers: make([]Transformer, 0, len(cfgs))}
+	for _, cfg := range cfgs {
+		t, err := build(cfg)
+		if err != nil {
+			return nil, err
+		}
+		c.transformers = append(c.transformers, t)
+	}
+	return c, nil
+}
+
+// Empty reports whether the chain has no transformers, letting callers skip
+// decoding/re-encoding the body entirely when nothing would change it.
+func (c *Chain) Empty() bool {
+	return c == nil || len(c.transformers) == 0
+}
+
+// Apply decodes resp's body per its Content-Encoding, runs every transformer
+// in order, re-encodes with the original encoding and recomputes
+// Content-Length. A nil or empty Chain is a no-op.
+func (c *Chain) Apply(resp *http.Response) error {
+	if c.Empty() {
+		return nil
+	}
+
+	encoding := resp.Header.Get("Content-Encoding")
+	raw, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	body, err := decode(encoding, raw)
+	if err != nil {
+		return err
+	}
+
+	ctx := &Context{Header: resp.Header, ContentType: resp.Header.Get("Content-Type"), Body: body}
+	for _, t := range c.transformers {
+		if err := t.Transform(ctx); err != nil {
+			return err
+		}
+	}
+
+	encoded, err := encode(encoding, ctx.Body)
+	if err != nil {
+		return err
+	}
+
+	resp.Body = ioutil.NopCloser(bytes.NewReader(encoded))
+	resp.ContentLength = int64(len(encoded))
+	resp.Header.Set("Content-Length", strconv.FormatInt(int64(len(encoded)), 10))
+	return nil
+}
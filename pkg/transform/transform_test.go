@@ -0,0 +1,106 @@
+package transform
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+// TestChainApplyGzipRoundTrip verifies Apply decodes a gzip-encoded body,
+// runs the configured transform, then re-encodes and recomputes
+// Content-Length, leaving the response's Content-Encoding untouched.
+func TestChainApplyGzipRoundTrip(t *testing.T) {
+	chain, err := NewChain([]Config{
+		{Type: "json_redact", JSONField: JSONFieldConfig{Path: "/token"}},
+	})
+	if err != nil {
+		t.Fatalf("NewChain: %v", err)
+	}
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	if _, err := gw.Write([]byte(`{"token":"secret","id":1}`)); err != nil {
+		t.Fatalf("gzip write: %v", err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatalf("gzip close: %v", err)
+	}
+
+	resp := &http.Response{
+		Header: http.Header{
+			"Content-Encoding": []string{"gzip"},
+			"Content-Type":     []string{"application/json"},
+		},
+		Body: ioutil.NopCloser(bytes.NewReader(buf.Bytes())),
+	}
+
+	if err := chain.Apply(resp); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+
+	if got := resp.Header.Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", got)
+	}
+
+	encoded, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read re-encoded body: %v", err)
+	}
+	gr, err := gzip.NewReader(bytes.NewReader(encoded))
+	if err != nil {
+		t.Fatalf("gzip.NewReader on re-encoded body: %v", err)
+	}
+	decoded, err := ioutil.ReadAll(gr)
+	if err != nil {
+		t.Fatalf("read decompressed body: %v", err)
+	}
+	if bytes.Contains(decoded, []byte("secret")) {
+		t.Fatalf("redacted field still present: %s", decoded)
+	}
+
+	wantLen := int64(len(encoded))
+	if resp.ContentLength != wantLen {
+		t.Fatalf("ContentLength = %d, want %d", resp.ContentLength, wantLen)
+	}
+	if got := resp.Header.Get("Content-Length"); got != "" {
+		n, err := strconv.ParseInt(got, 10, 64)
+		if err != nil {
+			t.Fatalf("parse Content-Length header %q: %v", got, err)
+		}
+		if n != wantLen {
+			t.Fatalf("Content-Length header = %d, want %d", n, wantLen)
+		}
+	}
+}
+
+// TestChainApplyEmptyChainIsNoop verifies a chain with no transformers never
+// touches the body, so callers relying on Empty() to skip decoding still see
+// Apply behave as a pure no-op if called anyway.
+func TestChainApplyEmptyChainIsNoop(t *testing.T) {
+	chain, err := NewChain(nil)
+	if err != nil {
+		t.Fatalf("NewChain: %v", err)
+	}
+	if !chain.Empty() {
+		t.Fatalf("expected empty chain")
+	}
+
+	body := []byte("unchanged")
+	resp := &http.Response{
+		Header: http.Header{},
+		Body:   ioutil.NopCloser(bytes.NewReader(body)),
+	}
+	if err := chain.Apply(resp); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	got, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	if !bytes.Equal(got, body) {
+		t.Fatalf("body = %q, want unchanged %q", got, body)
+	}
+}